@@ -0,0 +1,35 @@
+//go:build js && wasm
+
+package main
+
+// rangeBar is the position of the temperature range of a day within the
+// range of the forecast, in percent.
+type rangeBar struct {
+	Start float64
+	Width float64
+}
+
+// rangeBars sets the range bar of each day relative to the overall
+// range of the days. When every day has the same temperature, the bars
+// are centred.
+func rangeBars(days []day) {
+	if len(days) == 0 {
+		return
+	}
+
+	lo, hi := days[0].Temp.Min, days[0].Temp.Max
+	for _, d := range days[1:] {
+		lo, hi = min(lo, d.Temp.Min), max(hi, d.Temp.Max)
+	}
+
+	for i, d := range days {
+		if hi == lo {
+			days[i].Bar = &rangeBar{Start: 25, Width: 50}
+			continue
+		}
+		days[i].Bar = &rangeBar{
+			Start: (d.Temp.Min - lo) / (hi - lo) * 100,
+			Width: (d.Temp.Max - d.Temp.Min) / (hi - lo) * 100,
+		}
+	}
+}
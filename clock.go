@@ -0,0 +1,19 @@
+//go:build js && wasm
+
+package main
+
+import "time"
+
+// maxClockSkew is the largest difference between the device clock and
+// the observation time expected from a correct clock.
+const maxClockSkew = 3 * time.Hour
+
+// clockSkew returns how far the device clock is ahead of the observation
+// time of the current weather, or zero when within the expected skew.
+func clockSkew(now time.Time, c current) time.Duration {
+	skew := now.Sub(time.Unix(c.Unix, 0))
+	if skew.Abs() <= maxClockSkew {
+		return 0
+	}
+	return skew.Round(time.Minute)
+}
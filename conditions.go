@@ -0,0 +1,267 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Moderate temperatures in degrees Celsius.
+const (
+	moderateMin = 0
+	moderateMax = 30
+)
+
+// noteworthy reports whether the weather is remarkable, either through
+// an alert, conditions other than clear skies, expected precipitation or
+// extreme temperatures.
+func noteworthy(d data, units string) bool {
+	if d.Alert != "" {
+		return true
+	}
+
+	switch d.Current.Weather.code() {
+	case "", "01", "02":
+	default:
+		return true
+	}
+	if d.Current.Day.Rain > 0 || d.Current.Day.Pop >= 0.5 {
+		return true
+	}
+
+	temp := convertTemp(d.Current.Main.Temp, units, "metric")
+	return temp < moderateMin || temp > moderateMax
+}
+
+// Temperature anomalies.
+const (
+	anomalyWarm = "warm"
+	anomalyCold = "cold"
+)
+
+// anomaly returns whether the current temperature is unusually warm or
+// cold, differing from the seasonal normal of the month in the location
+// by more than the threshold. Normals are given for each month from
+// January.
+func anomaly(c current, normals []float64, threshold float64) string {
+	if len(normals) != 12 || c.Unix == 0 {
+		return ""
+	}
+
+	month := time.Unix(c.Unix, 0).In(c.Location()).Month()
+	diff := c.Main.Temp - normals[month-1]
+	switch {
+	case diff > threshold:
+		return anomalyWarm
+	case diff < -threshold:
+		return anomalyCold
+	default:
+		return ""
+	}
+}
+
+// Apparent temperatures, when it feels much hotter or colder than it is.
+const (
+	apparentHot  = "hot"
+	apparentCold = "cold"
+)
+
+// apparent returns whether the current temperature feels hotter or
+// colder than it is, by more than the threshold.
+func apparent(c current, threshold float64) string {
+	if c.Main.FeelsLike == nil {
+		return ""
+	}
+
+	switch diff := *c.Main.FeelsLike - c.Main.Temp; {
+	case diff > threshold:
+		return apparentHot
+	case diff < -threshold:
+		return apparentCold
+	default:
+		return ""
+	}
+}
+
+// bomApparent returns the apparent temperature in degrees Celsius using
+// the Australian Bureau of Meteorology formula, from the temperature in
+// degrees Celsius, the relative humidity in percent and the wind speed in
+// metres per second.
+func bomApparent(temp, humidity, wind float64) float64 {
+	vapour := humidity / 100 * 6.105 * math.Exp(17.27*temp/(237.7+temp))
+	return temp + 0.33*vapour - 0.70*wind - 4.00
+}
+
+// Temperature alerts.
+const (
+	alertFrost = "frost"
+	alertHeat  = "heat"
+)
+
+// alert returns the temperature alert for the current day, if the
+// temperature crosses either of the given thresholds.
+func alert(c current, frost, heat *float64) string {
+	lo, hi := c.Main.Temp, c.Main.Temp
+	if c.Day.Unix != 0 {
+		lo, hi = min(lo, c.Day.Temp.Min), max(hi, c.Day.Temp.Max)
+	}
+
+	switch {
+	case frost != nil && lo <= *frost:
+		return alertFrost
+	case heat != nil && hi >= *heat:
+		return alertHeat
+	default:
+		return ""
+	}
+}
+
+// Category returns the broad category of the weather, or an empty string
+// when the weather is unknown.
+func (w weather) Category() string {
+	switch w.code() {
+	case "01":
+		return "clear"
+	case "02", "03", "04":
+		return "cloudy"
+	case "09", "10":
+		return "rain"
+	case "11":
+		return "storm"
+	case "13":
+		return "snow"
+	case "50":
+		return "fog"
+	default:
+		return ""
+	}
+}
+
+// Night reports whether the weather is for the night.
+func (w weather) Night() bool {
+	return len(w) > 0 && strings.HasSuffix(w[0].IconCode, "n")
+}
+
+// background returns the background classes for the weather, or an empty
+// string when the weather is unknown.
+func background(w weather) string {
+	cat := w.Category()
+	switch {
+	case cat == "":
+		return ""
+	case w.Night():
+		return "bg-" + cat + " night"
+	default:
+		return "bg-" + cat
+	}
+}
+
+// Humidity comfort bands, in relative humidity percent, and the
+// temperature in degrees Celsius above which humid air feels muggy.
+const (
+	dryHumidity      = 30
+	humidHumidity    = 60
+	muggyHumidity    = 70
+	muggyTemperature = 20
+)
+
+// comfort returns how the humidity feels at the given temperature in
+// degrees Celsius.
+func comfort(humidity, temp float64) string {
+	switch {
+	case humidity < dryHumidity:
+		return "dry"
+	case humidity <= humidHumidity:
+		return "comfortable"
+	case humidity > muggyHumidity && temp >= muggyTemperature:
+		return "muggy"
+	default:
+		return "humid"
+	}
+}
+
+// overcastClouds is the cloud coverage percentage above which the sky
+// is considered overcast.
+const overcastClouds = 75
+
+// mood returns a coarse mood of the current weather for styling, or an
+// empty string when the weather is unknown.
+func mood(c current) string {
+	switch c.Weather.Category() {
+	case "":
+		return ""
+	case "storm":
+		return "stormy"
+	case "rain", "fog":
+		return "gloomy"
+	case "snow":
+		return "crisp"
+	}
+
+	switch {
+	case c.Clouds.All >= overcastClouds:
+		return "gloomy"
+	case c.Weather.Night():
+		return "calm"
+	default:
+		return "bright"
+	}
+}
+
+// overrideDescription is the icon override that derives the icon code
+// from the weather description.
+const overrideDescription = "description"
+
+// descriptionCodes maps words in weather descriptions to icon codes,
+// without the time of day, in order of precedence.
+var descriptionCodes = []struct {
+	word string
+	code string
+}{
+	{word: "thunder", code: "11"},
+	{word: "snow", code: "13"},
+	{word: "sleet", code: "13"},
+	{word: "drizzle", code: "09"},
+	{word: "rain", code: "10"},
+	{word: "fog", code: "50"},
+	{word: "mist", code: "50"},
+	{word: "haze", code: "50"},
+	{word: "overcast", code: "04"},
+	{word: "broken", code: "04"},
+	{word: "scattered", code: "03"},
+	{word: "few", code: "02"},
+	{word: "partly", code: "02"},
+	{word: "cloud", code: "03"},
+	{word: "clear", code: "01"},
+	{word: "sun", code: "01"},
+}
+
+// override returns the weather with its icon code replaced by the given
+// overrides, which map codes to either another code or to the code
+// derived from the description.
+func (w weather) override(overrides map[string]string) weather {
+	if len(w) == 0 {
+		return w
+	}
+	to, ok := overrides[w[0].IconCode]
+	if !ok {
+		return w
+	}
+
+	w = slices.Clone(w)
+	if to == overrideDescription {
+		desc := strings.ToLower(w[0].Description)
+		for _, dc := range descriptionCodes {
+			if strings.Contains(desc, dc.word) {
+				w[0].IconCode = dc.code + w[0].IconCode[len(w[0].IconCode)-1:]
+				break
+			}
+		}
+		return w
+	}
+	w[0].IconCode = to
+	return w
+}
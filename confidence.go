@@ -0,0 +1,43 @@
+//go:build js && wasm
+
+package main
+
+import "time"
+
+// Data confidence levels.
+const (
+	confidenceHigh   = "high"
+	confidenceMedium = "medium"
+	confidenceLow    = "low"
+)
+
+// Observation ages beyond which the data is less trusted.
+const (
+	freshAge = time.Hour
+	staleAge = 3 * time.Hour
+)
+
+// result is the outcome of running a set of jobs.
+type result struct {
+	retries  int
+	failures int
+	// primaryFailures is the number of failures of jobs that are not
+	// optional, without which the weather cannot be displayed.
+	primaryFailures int
+	// keyFailures is the number of failures of jobs that are not optional
+	// caused by the app id being invalid or rate limited.
+	keyFailures int
+}
+
+// confidence returns how far the data can be trusted, based on how the
+// requests went and the age of the observation.
+func confidence(r result, age time.Duration) string {
+	switch {
+	case r.failures > 0 || age > staleAge:
+		return confidenceLow
+	case r.retries > 0 || age > freshAge:
+		return confidenceMedium
+	default:
+		return confidenceHigh
+	}
+}
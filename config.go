@@ -0,0 +1,489 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config is the module configuration.
+type Config struct {
+	Provider     string            `yaml:"provider"`
+	LocationID   string            `yaml:"locationId"`
+	LocationName string            `yaml:"locationName"`
+	Lat          *float64          `yaml:"lat"`
+	Lon          *float64          `yaml:"lon"`
+	AppID        string            `yaml:"appId"`
+	ExtraParams  map[string]string `yaml:"extraParams"`
+	Units        string            `yaml:"units"`
+	DisplayUnits string            `yaml:"displayUnits"`
+	TempUnit     string            `yaml:"tempUnit"`
+	WindUnit     string            `yaml:"windUnit"`
+	PressureUnit string            `yaml:"pressureUnit"`
+	Interval     time.Duration     `yaml:"interval"`
+
+	AllowFastInterval bool `yaml:"allowFastInterval"`
+
+	CacheGeocoding bool `yaml:"cacheGeocoding"`
+
+	FallbackAppID    string        `yaml:"fallbackAppId"`
+	FallbackCooldown time.Duration `yaml:"fallbackCooldown"`
+
+	Timeout          time.Duration `yaml:"timeout"`
+	Retries          int           `yaml:"retries"`
+	RetryDelay       time.Duration `yaml:"retryDelay"`
+	RetryJitter      string        `yaml:"retryJitter"`
+	Strict           bool          `yaml:"strict"`
+	MaxStaleFailures int           `yaml:"maxStaleFailures"`
+	Concurrency      int           `yaml:"concurrency"`
+	FollowRedirects  bool          `yaml:"followRedirects"`
+	BaseURL          string        `yaml:"baseUrl"`
+
+	DeferFirstRender bool `yaml:"deferFirstRender"`
+	RefreshOnOnline  bool `yaml:"refreshOnOnline"`
+
+	ForecastDays    int               `yaml:"forecastDays"`
+	ForecastRows    int               `yaml:"forecastRows"`
+	IncludeToday    bool              `yaml:"includeToday"`
+	TodayLabel      string            `yaml:"todayLabel"`
+	TomorrowLabel   string            `yaml:"tomorrowLabel"`
+	RelativeDays    bool              `yaml:"relativeDays"`
+	WindPrecision   int               `yaml:"windPrecision"`
+	WindArrow       bool              `yaml:"windArrow"`
+	Theme           string            `yaml:"theme"`
+	Accent          string            `yaml:"accent"`
+	IconStyle       string            `yaml:"iconStyle"`
+	ShowCurrentIcon bool              `yaml:"showCurrentIcon"`
+	UnknownIcon     string            `yaml:"unknownIcon"`
+	IconOverrides   map[string]string `yaml:"iconOverrides"`
+	IconFallback    string            `yaml:"iconFallback"`
+	Orientation     string            `yaml:"orientation"`
+	Accessibility   bool              `yaml:"accessibility"`
+	Attribution     bool              `yaml:"attribution"`
+
+	DynamicBackground bool `yaml:"dynamicBackground"`
+
+	CompactForecast  bool   `yaml:"compactForecast"`
+	MinimalForecast  bool   `yaml:"minimalForecast"`
+	CurrentLayout    string `yaml:"currentLayout"`
+	ForecastLayout   string `yaml:"forecastLayout"`
+	RangeBars        bool   `yaml:"rangeBars"`
+	ForecastTemp     string `yaml:"forecastTemp"`
+	HighlightWeekend bool   `yaml:"highlightWeekend"`
+	TimeFormat       string `yaml:"timeFormat"`
+	SignedTemps      bool   `yaml:"signedTemps"`
+	PopFormat        string `yaml:"popFormat"`
+	ShowUmbrella     bool   `yaml:"showUmbrella"`
+
+	HidePrecipWhenZero bool `yaml:"hidePrecipWhenZero"`
+
+	TempPrecision      int `yaml:"tempPrecision"`
+	RainPrecision      int `yaml:"rainPrecision"`
+	WindSpeedPrecision int `yaml:"windSpeedPrecision"`
+
+	CollapseWhenClear bool `yaml:"collapseWhenClear"`
+	ShowTomorrow      bool `yaml:"showTomorrow"`
+
+	MetricsOrder []string `yaml:"metricsOrder"`
+	ShowComfort  bool     `yaml:"showComfort"`
+	ShowNowcast  bool     `yaml:"showNowcast"`
+	OneCall      bool     `yaml:"oneCall"`
+
+	ShowGoldenHour bool `yaml:"showGoldenHour"`
+	ShowDaylight   bool `yaml:"showDaylight"`
+	SunCountdown   bool `yaml:"sunCountdown"`
+	ShowPollen     bool `yaml:"showPollen"`
+	ShowConfidence bool `yaml:"showConfidence"`
+	ShowSparkline  bool `yaml:"showSparkline"`
+	ShowRainTotal  bool `yaml:"showRainTotal"`
+
+	ShowPressureTendency bool `yaml:"showPressureTendency"`
+
+	FrostThreshold *float64 `yaml:"frostThreshold"`
+	HeatThreshold  *float64 `yaml:"heatThreshold"`
+	SmoothTemp     float64  `yaml:"smoothTemp"`
+
+	ApparentTemp       string  `yaml:"apparentTemp"`
+	FeelsLikeBadge     bool    `yaml:"feelsLikeBadge"`
+	FeelsLikeThreshold float64 `yaml:"feelsLikeThreshold"`
+
+	SeasonalNormals  []float64 `yaml:"seasonalNormals"`
+	AnomalyThreshold float64   `yaml:"anomalyThreshold"`
+}
+
+// NewConfig returns a Config with default values set.
+func NewConfig() Config {
+	return Config{
+		Provider:        "openweathermap",
+		Units:           "standard",
+		Interval:        30 * time.Minute,
+		Timeout:         30 * time.Second,
+		RetryDelay:      time.Second,
+		RetryJitter:     "full",
+		Concurrency:     1,
+		FollowRedirects: true,
+		ForecastDays:    3,
+		ForecastRows:    1,
+		ForecastTemp:    "minmax",
+		WindPrecision:   8,
+		Theme:           "classic",
+		IconStyle:       "font",
+		ShowCurrentIcon: true,
+		UnknownIcon:     unknownIcon,
+		IconFallback:    "none",
+		Orientation:     "horizontal",
+		Attribution:     true,
+		TimeFormat:      "15:04",
+		PopFormat:       "percent",
+		MetricsOrder:    []string{"max", "min", "rain"},
+
+		FallbackCooldown:   time.Hour,
+		AnomalyThreshold:   3,
+		HidePrecipWhenZero: true,
+		ApparentTemp:       "api",
+		FeelsLikeThreshold: 5,
+	}
+}
+
+// Validate validates the configuration, returning all problems found.
+func (c Config) Validate() error {
+	var errs []error
+	switch c.Provider {
+	case "openweathermap":
+	case "weatherapi":
+		if c.LocationName == "" && c.Lat == nil {
+			errs = append(errs, errors.New("provider weatherapi requires locationName or lat and lon"))
+		}
+		if c.ShowNowcast {
+			errs = append(errs, errors.New("showNowcast is only supported by provider openweathermap"))
+		}
+		if c.ShowSparkline {
+			errs = append(errs, errors.New("showSparkline is only supported by provider openweathermap"))
+		}
+		if c.ShowRainTotal {
+			errs = append(errs, errors.New("showRainTotal is only supported by provider openweathermap"))
+		}
+		if c.OneCall {
+			errs = append(errs, errors.New("oneCall is only supported by provider openweathermap"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("provider %q must be openweathermap or weatherapi", c.Provider))
+	}
+	if (c.Lat == nil) != (c.Lon == nil) {
+		errs = append(errs, errors.New("lat and lon must be set together"))
+	}
+	if c.LocationID == "" && c.LocationName == "" && c.Lat == nil {
+		errs = append(errs, errors.New("one of locationId, locationName or lat and lon is required"))
+	}
+	if c.AppID == "" {
+		errs = append(errs, errors.New("appId is required"))
+	}
+	if c.BaseURL != "" {
+		if u, err := url.Parse(c.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("baseUrl %q must be an http or https url", c.BaseURL))
+		}
+	}
+	if c.FallbackCooldown <= 0 {
+		errs = append(errs, fmt.Errorf("fallbackCooldown %s must be greater than zero", c.FallbackCooldown))
+	}
+	for _, p := range protectedParams {
+		if _, ok := c.ExtraParams[p]; ok {
+			errs = append(errs, fmt.Errorf("extraParams cannot override %q", p))
+		}
+	}
+	if c.ShowNowcast && c.Lat == nil && c.LocationName == "" {
+		errs = append(errs, errors.New("showNowcast requires locationName or lat and lon"))
+	}
+	if c.OneCall && c.Lat == nil && c.LocationName == "" {
+		errs = append(errs, errors.New("oneCall requires locationName or lat and lon"))
+	}
+	switch c.Units {
+	case "metric", "imperial", "standard":
+	default:
+		errs = append(errs, fmt.Errorf("units %q must be one of metric, imperial or standard", c.Units))
+	}
+	switch c.DisplayUnits {
+	case "", "metric", "imperial", "standard":
+	default:
+		errs = append(errs, fmt.Errorf("displayUnits %q must be one of metric, imperial or standard", c.DisplayUnits))
+	}
+	if _, ok := tempUnits[c.TempUnit]; c.TempUnit != "" && !ok {
+		errs = append(errs, fmt.Errorf("tempUnit %q must be one of C, F or K", c.TempUnit))
+	}
+	if _, ok := speedUnits[c.WindUnit]; c.WindUnit != "" && !ok {
+		errs = append(errs, fmt.Errorf("windUnit %q must be one of m/s, km/h, mph or kn", c.WindUnit))
+	}
+	if _, ok := pressureUnits[c.PressureUnit]; c.PressureUnit != "" && !ok {
+		errs = append(errs, fmt.Errorf("pressureUnit %q must be one of hPa, kPa, inHg or mmHg", c.PressureUnit))
+	}
+	if c.Interval <= 0 {
+		errs = append(errs, fmt.Errorf("interval %s must be greater than zero", c.Interval))
+	} else if c.Interval < minInterval && !c.AllowFastInterval {
+		errs = append(errs, fmt.Errorf("interval %s must be at least %s unless allowFastInterval is set", c.Interval, minInterval))
+	}
+	if c.Timeout < 0 {
+		errs = append(errs, fmt.Errorf("timeout %s must not be negative", c.Timeout))
+	}
+	if c.Retries < 0 {
+		errs = append(errs, fmt.Errorf("retries %d must not be negative", c.Retries))
+	}
+	if c.RetryDelay < 0 {
+		errs = append(errs, fmt.Errorf("retryDelay %s must not be negative", c.RetryDelay))
+	}
+	switch c.RetryJitter {
+	case "none", "full", "equal":
+	default:
+		errs = append(errs, fmt.Errorf("retryJitter %q must be one of none, full or equal", c.RetryJitter))
+	}
+	if c.MaxStaleFailures < 0 {
+		errs = append(errs, fmt.Errorf("maxStaleFailures %d must not be negative", c.MaxStaleFailures))
+	}
+	if c.Concurrency < 1 {
+		errs = append(errs, fmt.Errorf("concurrency %d must be at least 1", c.Concurrency))
+	}
+	if c.ForecastDays < 1 {
+		errs = append(errs, fmt.Errorf("forecastDays %d must be at least 1", c.ForecastDays))
+	}
+	if c.ForecastRows < 1 {
+		errs = append(errs, fmt.Errorf("forecastRows %d must be at least 1", c.ForecastRows))
+	}
+	for _, p := range []struct {
+		name string
+		val  int
+	}{
+		{name: "tempPrecision", val: c.TempPrecision},
+		{name: "rainPrecision", val: c.RainPrecision},
+		{name: "windSpeedPrecision", val: c.WindSpeedPrecision},
+	} {
+		if p.val < 0 || p.val > maxPrecision {
+			errs = append(errs, fmt.Errorf("%s %d must be between 0 and %d", p.name, p.val, maxPrecision))
+		}
+	}
+	if c.WindPrecision != 8 && c.WindPrecision != 16 {
+		errs = append(errs, fmt.Errorf("windPrecision %d must be 8 or 16", c.WindPrecision))
+	}
+	switch c.IconStyle {
+	case "font", "emoji":
+	default:
+		errs = append(errs, fmt.Errorf("iconStyle %q must be font or emoji", c.IconStyle))
+	}
+	froms := make([]string, 0, len(c.IconOverrides))
+	for from := range c.IconOverrides {
+		froms = append(froms, from)
+	}
+	sort.Strings(froms)
+	for _, from := range froms {
+		to := c.IconOverrides[from]
+		if _, ok := iconTable[from]; !ok {
+			errs = append(errs, fmt.Errorf("iconOverrides code %q is not a known icon code", from))
+		}
+		if _, ok := iconTable[to]; !ok && to != overrideDescription {
+			errs = append(errs, fmt.Errorf("iconOverrides code %q must be a known icon code or %s", to, overrideDescription))
+		}
+	}
+	switch c.IconFallback {
+	case "none", "emoji":
+	default:
+		errs = append(errs, fmt.Errorf("iconFallback %q must be none or emoji", c.IconFallback))
+	}
+	switch c.ForecastTemp {
+	case "minmax", "day":
+	case "morn", "eve", "night":
+		if c.Provider == "weatherapi" {
+			errs = append(errs, fmt.Errorf("forecastTemp %q is not supported by provider weatherapi", c.ForecastTemp))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("forecastTemp %q must be one of minmax, morn, day, eve or night", c.ForecastTemp))
+	}
+	switch c.Orientation {
+	case "horizontal", "vertical":
+	default:
+		errs = append(errs, fmt.Errorf("orientation %q must be horizontal or vertical", c.Orientation))
+	}
+	if _, err := theme(c.Theme); err != nil {
+		errs = append(errs, fmt.Errorf("theme %q must be one of classic, minimal, detailed or hero", c.Theme))
+	}
+	switch c.ApparentTemp {
+	case "api", "bom", "off":
+	default:
+		errs = append(errs, fmt.Errorf("apparentTemp %q must be one of api, bom or off", c.ApparentTemp))
+	}
+	if c.Accent != "" && !isHexColor(c.Accent) {
+		errs = append(errs, fmt.Errorf("accent %q must be a hex color, such as #rgb or #rrggbb", c.Accent))
+	}
+	if c.FeelsLikeThreshold <= 0 {
+		errs = append(errs, fmt.Errorf("feelsLikeThreshold %g must be greater than zero", c.FeelsLikeThreshold))
+	}
+	if c.SmoothTemp < 0 || c.SmoothTemp >= 1 {
+		errs = append(errs, fmt.Errorf("smoothTemp %g must be at least 0 and less than 1", c.SmoothTemp))
+	}
+	if n := len(c.SeasonalNormals); n != 0 && n != 12 {
+		errs = append(errs, fmt.Errorf("seasonalNormals must have a temperature for each of the 12 months, got %d", n))
+	}
+	if c.AnomalyThreshold <= 0 {
+		errs = append(errs, fmt.Errorf("anomalyThreshold %g must be greater than zero", c.AnomalyThreshold))
+	}
+	switch c.CurrentLayout {
+	case "", "full", "collapsed", "text":
+	default:
+		errs = append(errs, fmt.Errorf("currentLayout %q must be one of full, collapsed or text", c.CurrentLayout))
+	}
+	switch c.ForecastLayout {
+	case "", "full", "compact", "minimal":
+	default:
+		errs = append(errs, fmt.Errorf("forecastLayout %q must be one of full, compact or minimal", c.ForecastLayout))
+	}
+	switch c.PopFormat {
+	case "percent", "fraction", "decimal":
+	default:
+		errs = append(errs, fmt.Errorf("popFormat %q must be one of percent, fraction or decimal", c.PopFormat))
+	}
+	return errors.Join(errs...)
+}
+
+// maxPrecision is the most decimals that can be displayed.
+const maxPrecision = 3
+
+// precision returns the number of decimals displayed for each field.
+func (c Config) precision() precision {
+	return precision{
+		Temp: c.TempPrecision,
+		Rain: c.RainPrecision,
+		Wind: c.WindSpeedPrecision,
+	}
+}
+
+// currentLayout returns the layout of the current weather.
+func (c Config) currentLayout() string {
+	if c.CurrentLayout != "" {
+		return c.CurrentLayout
+	}
+	return "full"
+}
+
+// forecastLayout returns the layout of the forecast days, falling back
+// to the compact and minimal forecast options when it is not set.
+func (c Config) forecastLayout() string {
+	switch {
+	case c.ForecastLayout != "":
+		return c.ForecastLayout
+	case c.MinimalForecast:
+		return "minimal"
+	case c.CompactForecast:
+		return "compact"
+	default:
+		return "full"
+	}
+}
+
+// baseURL returns the url OpenWeatherMap data is requested from.
+func (c Config) baseURL() string {
+	if c.BaseURL != "" {
+		return c.BaseURL
+	}
+	return api
+}
+
+// dataPath is the versioned path of the weather data in the base url.
+const dataPath = "/data/2.5"
+
+// rootURL returns the url the other OpenWeatherMap APIs are requested
+// from, which is the base url without the path of the weather data, so a
+// proxy of the whole API is used for all of them.
+func (c Config) rootURL() string {
+	return strings.TrimSuffix(strings.TrimSuffix(c.baseURL(), "/"), dataPath)
+}
+
+// fetchUnits returns the units data is fetched in. When display units
+// are set, data is fetched in standard units and converted.
+func (c Config) fetchUnits() string {
+	if c.DisplayUnits != "" {
+		return "standard"
+	}
+	return c.Units
+}
+
+// displayUnits returns the units data is displayed in.
+func (c Config) displayUnits() string {
+	if c.DisplayUnits != "" {
+		return c.DisplayUnits
+	}
+	return c.Units
+}
+
+// needsHourly reports whether the 3 hour forecast is displayed.
+func (c Config) needsHourly() bool {
+	return c.ShowSparkline || c.ShowRainTotal
+}
+
+// tempUnits returns the units temperatures are displayed in.
+func (c Config) tempUnits() string {
+	if units, ok := tempUnits[c.TempUnit]; ok {
+		return units
+	}
+	return c.displayUnits()
+}
+
+// windUnit returns the unit wind speeds are displayed in.
+func (c Config) windUnit() string {
+	if c.WindUnit != "" {
+		return c.WindUnit
+	}
+	return windUnit(c.displayUnits())
+}
+
+// pressureUnit returns the unit pressures are displayed in.
+func (c Config) pressureUnit() string {
+	if c.PressureUnit != "" {
+		return c.PressureUnit
+	}
+	return "hPa"
+}
+
+// isHexColor reports whether the string is a CSS hex color in the short
+// (#rgb) or long (#rrggbb) form.
+func isHexColor(s string) bool {
+	if (len(s) != 4 && len(s) != 7) || s[0] != '#' {
+		return false
+	}
+	_, err := strconv.ParseUint(s[1:], 16, 32)
+	return err == nil
+}
+
+// withDefaultInterval returns the config with an interval that is not
+// greater than zero replaced by the default, reporting whether it was
+// replaced.
+func (c Config) withDefaultInterval() (Config, bool) {
+	if c.Interval > 0 {
+		return c, false
+	}
+	c.Interval = NewConfig().Interval
+	return c, true
+}
+
+// minInterval is the shortest interval allowed, unless fast intervals
+// are explicitly allowed, to protect the API quota.
+const minInterval = 10 * time.Minute
+
+// checkTiming returns an error when a request, including its retries,
+// could take longer than the interval, causing updates to overlap.
+func (c Config) checkTiming() error {
+	if c.Timeout <= 0 {
+		return nil
+	}
+
+	worst := c.Timeout * time.Duration(c.Retries+1)
+	for i := range c.Retries {
+		worst += backoff(i, c.RetryDelay, "none")
+	}
+	if worst >= c.Interval {
+		return fmt.Errorf("timeout %s with %d retries could exceed interval %s", c.Timeout, c.Retries, c.Interval)
+	}
+	return nil
+}
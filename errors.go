@@ -0,0 +1,68 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Errors returned when requesting weather data, distinguishing why the
+// request failed.
+var (
+	ErrNetwork       = errors.New("network error")
+	ErrDecode        = errors.New("decode error")
+	ErrContentType   = fmt.Errorf("%w: unexpected content type", ErrDecode)
+	ErrAPI           = errors.New("api error")
+	ErrInvalidAPIKey = fmt.Errorf("%w: invalid api key", ErrAPI)
+	ErrRateLimited   = fmt.Errorf("%w: rate limited", ErrAPI)
+)
+
+// apiError returns the error of an API error response, from either the
+// status code or the code in the response.
+func apiError(status int, c code, msg string) error {
+	err := ErrAPI
+	switch {
+	case status == http.StatusUnauthorized || c == "401":
+		err = ErrInvalidAPIKey
+	case status == http.StatusTooManyRequests || c == "429":
+		err = ErrRateLimited
+	}
+	return fmt.Errorf("could not fetch data: %w: %s", err, msg)
+}
+
+// isJSON reports whether the content type is JSON. A missing content
+// type is assumed to be JSON.
+func isJSON(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	typ, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return typ == "application/json" || strings.HasSuffix(typ, "+json")
+}
+
+// reason returns a short description of why a request failed.
+func reason(err error) string {
+	switch {
+	case errors.Is(err, ErrInvalidAPIKey):
+		return "invalid app id"
+	case errors.Is(err, ErrRateLimited):
+		return "rate limited"
+	case errors.Is(err, ErrAPI):
+		return "api error"
+	case errors.Is(err, ErrContentType):
+		return "unexpected content type, likely a captive portal"
+	case errors.Is(err, ErrDecode):
+		return "unexpected response"
+	case errors.Is(err, ErrNetwork):
+		return "network error"
+	default:
+		return "unknown"
+	}
+}
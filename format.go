@@ -0,0 +1,98 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// precision is the number of decimals displayed for each field.
+type precision struct {
+	Temp int
+	Rain int
+	Wind int
+}
+
+// formatNumber formats a number with the given number of decimals,
+// without a sign when it rounds to zero.
+func formatNumber(n float64, decimals int) string {
+	s := strconv.FormatFloat(n, 'f', decimals, 64)
+	if strings.Trim(s, "-0.") == "" {
+		return strings.TrimPrefix(s, "-")
+	}
+	return s
+}
+
+// formatTemp formats a temperature with the given number of decimals,
+// with a leading plus sign for positive temperatures when signed.
+func formatTemp(temp float64, decimals int, signed bool) string {
+	s := formatNumber(temp, decimals)
+	if signed && strings.Trim(s, "0.") != "" && !strings.HasPrefix(s, "-") {
+		return "+" + s
+	}
+	return s
+}
+
+// Precipitation returns the probability and amount of rain for the day,
+// formatted as configured for display. A zero probability or amount is
+// omitted when zero precipitation is hidden, returning an empty string
+// when no rain is expected.
+func (d day) Precipitation(disp display) string {
+	var parts []string
+	if d.Pop > 0 || !disp.HideZeroPrecip {
+		parts = append(parts, formatPop(float64(d.Pop), disp.PopFormat))
+	}
+	switch {
+	case d.Rain > 0:
+		parts = append(parts, formatRain(float64(d.Rain), disp.Precision.Rain))
+	case !disp.HideZeroPrecip:
+		parts = append(parts, formatNumber(0, disp.Precision.Rain)+"mm")
+	}
+	return strings.Join(parts, " · ")
+}
+
+// PopPercent returns the probability of precipitation as a percentage.
+func (d day) PopPercent() int {
+	return int(math.Round(float64(d.Pop) * 100))
+}
+
+// formatPop formats a probability of precipitation between 0 and 1 as a
+// percentage, a fraction of 10 or a decimal.
+func formatPop(pop float64, format string) string {
+	switch format {
+	case "fraction":
+		return fmt.Sprintf("%.f/10", pop*10)
+	case "decimal":
+		return strconv.FormatFloat(math.Round(pop*100)/100, 'f', -1, 64)
+	default:
+		return fmt.Sprintf("%.f%%", pop*100)
+	}
+}
+
+// formatRainTotal formats a total amount of rain in millimetres, in
+// inches for imperial units, or returns an empty string when no rain is
+// expected.
+func formatRainTotal(mm float64, decimals int, units string) string {
+	switch {
+	case mm <= 0:
+		return ""
+	case units == "imperial":
+		return fmt.Sprintf("%.2fin", mm/25.4)
+	default:
+		return formatRain(mm, decimals)
+	}
+}
+
+// formatRain formats an amount of rain in millimetres with the given
+// number of decimals, showing amounts too small to display as less than
+// the smallest displayed amount.
+func formatRain(mm float64, decimals int) string {
+	s := formatNumber(mm, decimals)
+	if strings.Trim(s, "0.") == "" {
+		return "<" + strconv.FormatFloat(math.Pow10(-decimals), 'f', decimals, 64) + "mm"
+	}
+	return s + "mm"
+}
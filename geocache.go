@@ -0,0 +1,38 @@
+//go:build js && wasm
+
+package main
+
+import "strings"
+
+// geocodeCacheKey is the local storage key of the cached location.
+const geocodeCacheKey = "glasslabs.weather.geocode"
+
+// geocodeCache is a resolved location kept in local storage, so the
+// location is not resolved again on each restart.
+type geocodeCache struct {
+	Location string  `json:"location"`
+	Lat      float64 `json:"lat"`
+	Lon      float64 `json:"lon"`
+}
+
+// normalizeLocation returns the location name in the form the cache is
+// keyed by.
+func normalizeLocation(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// loadGeocode returns the cached coordinates of the location name. A
+// missing or corrupt cache, or one for another location, is ignored.
+func (m *Module) loadGeocode(name string) *coordinates {
+	var gc geocodeCache
+	if !m.loadLocal(geocodeCacheKey, &gc) || gc.Location != normalizeLocation(name) {
+		return nil
+	}
+	return &coordinates{Lat: gc.Lat, Lon: gc.Lon}
+}
+
+// storeGeocode caches the coordinates of the location name, replacing
+// any previously cached location.
+func (m *Module) storeGeocode(name string, c coordinates) {
+	m.storeLocal(geocodeCacheKey, geocodeCache{Location: normalizeLocation(name), Lat: c.Lat, Lon: c.Lon})
+}
@@ -0,0 +1,123 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+)
+
+// geoPath is the path of the geocoding API, relative to the root url.
+const geoPath = "geo/1.0/direct"
+
+type coordinates struct {
+	Lat float64
+	Lon float64
+}
+
+type geoLocation struct {
+	Name    string  `json:"name"`
+	State   string  `json:"state"`
+	Country string  `json:"country"`
+	Lat     float64 `json:"lat"`
+	Lon     float64 `json:"lon"`
+}
+
+// geocode resolves the configured location name to coordinates,
+// caching them on the module.
+func (m *Module) geocode() error {
+	if m.cfg.CacheGeocoding {
+		if c := m.loadGeocode(m.cfg.LocationName); c != nil {
+			m.log.Info("Using cached location",
+				"location", m.cfg.LocationName,
+				"coordinates", fmt.Sprintf("%g,%g", c.Lat, c.Lon),
+			)
+
+			m.mu.Lock()
+			defer m.mu.Unlock()
+
+			m.coords = c
+			return nil
+		}
+	}
+
+	q := url.Values{}
+	q.Set("q", m.cfg.LocationName)
+	q.Set("limit", strconv.Itoa(1))
+	q.Set("appid", m.appID())
+
+	u, err := url.JoinPath(m.cfg.rootURL(), geoPath)
+	if err != nil {
+		return fmt.Errorf("could not parse url: %w", err)
+	}
+	var locs []geoLocation
+	if err = m.get(u, q, &locs); err != nil {
+		return err
+	}
+	if len(locs) == 0 {
+		return errors.New("location not found")
+	}
+
+	loc := locs[0]
+	m.log.Info("Resolved location",
+		"location", m.cfg.LocationName,
+		"name", loc.Name,
+		"state", loc.State,
+		"country", loc.Country,
+		"coordinates", fmt.Sprintf("%g,%g", loc.Lat, loc.Lon),
+	)
+
+	c := coordinates{Lat: loc.Lat, Lon: loc.Lon}
+	if m.cfg.CacheGeocoding {
+		m.storeGeocode(m.cfg.LocationName, c)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.coords = &c
+	return nil
+}
+
+// cityDistance is the distance in kilometres beyond which the city of a
+// forecast is considered to not match the coordinates.
+const cityDistance = 50
+
+// checkCity logs, once, when the city of a forecast is far from the
+// configured coordinates, which suggests the coordinates are wrong.
+func (m *Module) checkCity(c city) {
+	coords := m.location()
+	if m.cityChecked || coords == nil || c.Name == "" {
+		return
+	}
+	m.cityChecked = true
+
+	if dist := distance(*coords, coordinates{Lat: c.Coord.Lat, Lon: c.Coord.Lon}); dist > cityDistance {
+		m.log.Info("Forecast city is far from the location",
+			"city", c.Name,
+			"distance", fmt.Sprintf("%.fkm", dist),
+		)
+	}
+}
+
+// distance returns the great circle distance between the coordinates in
+// kilometres.
+func distance(a, b coordinates) float64 {
+	const earthRadius = 6371
+
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat, dLon := lat2-lat1, (b.Lon-a.Lon)*math.Pi/180
+	h := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dLon/2), 2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
+
+// location returns the resolved coordinates, if any.
+func (m *Module) location() *coordinates {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.coords
+}
@@ -2,9 +2,9 @@ module github.com/glasslabs/weather
 
 go 1.22
 
-require github.com/glasslabs/client-go v0.2.0
-
 require (
-	gopkg.in/yaml.v3 v3.0.1 // indirect
-	honnef.co/go/js/dom/v2 v2.0.0-20231112215516-51f43a291193 // indirect
+	github.com/glasslabs/client-go v0.2.0
+	honnef.co/go/js/dom/v2 v2.0.0-20231112215516-51f43a291193
 )
+
+require gopkg.in/yaml.v3 v3.0.1 // indirect
@@ -0,0 +1,18 @@
+//go:build js && wasm
+
+package main
+
+import "net/http"
+
+// fetchRedirectHeader sets the redirect mode of requests made through
+// the browser, which follows redirects without consulting the client.
+const fetchRedirectHeader = "js.fetch:redirect"
+
+// newHTTPClient returns an HTTP client configured for long running use.
+// Requests are made through the browser, which manages connections and
+// redirects itself.
+func newHTTPClient(cfg Config) *http.Client {
+	return &http.Client{
+		Timeout: cfg.Timeout,
+	}
+}
@@ -0,0 +1,47 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"strconv"
+	"time"
+)
+
+// keyFailureLimit is the number of updates in a row that fail with an
+// invalid or rate limited app id before the fallback app id is used.
+const keyFailureLimit = 3
+
+// appID returns the app id to request weather data with.
+func (m *Module) appID() string {
+	if m.fallbackSince.IsZero() {
+		return m.cfg.AppID
+	}
+	return m.cfg.FallbackAppID
+}
+
+// observeKey switches between the primary and fallback app ids based on
+// the result of an update. The update lock must be held.
+func (m *Module) observeKey(r result) {
+	if m.cfg.FallbackAppID == "" {
+		return
+	}
+
+	if !m.fallbackSince.IsZero() {
+		if time.Since(m.fallbackSince) >= m.cfg.FallbackCooldown {
+			m.log.Info("Switching back to the primary app id")
+			m.fallbackSince = time.Time{}
+			m.keyFailures = 0
+		}
+		return
+	}
+
+	if r.keyFailures == 0 {
+		m.keyFailures = 0
+		return
+	}
+	m.keyFailures++
+	if m.keyFailures >= keyFailureLimit {
+		m.log.Info("Switching to the fallback app id", "failures", strconv.Itoa(m.keyFailures))
+		m.fallbackSince = time.Now()
+	}
+}
@@ -4,16 +4,23 @@ package main
 
 import (
 	"bytes"
-	_ "embed"
+	"cmp"
+	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"io"
 	"net/http"
 	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/glasslabs/client-go"
+	"honnef.co/go/js/dom/v2"
 )
 
 const (
@@ -29,24 +36,12 @@ var (
 	//go:embed assets/wu-icons-style.css
 	icons []byte
 
-	//go:embed assets/index.html
-	html []byte
-)
-
-// Config is the module configuration.
-type Config struct {
-	LocationID string        `yaml:"locationId"`
-	AppID      string        `yaml:"appId"`
-	Units      string        `yaml:"units"`
-	Interval   time.Duration `yaml:"interval"`
-}
+	//go:embed assets/themes/*.html
+	themes embed.FS
 
-// NewConfig returns a Config with default values set.
-func NewConfig() Config {
-	return Config{
-		Interval: 30 * time.Minute,
-	}
-}
+	//go:embed assets/partials.html
+	partials []byte
+)
 
 func main() {
 	log := client.NewLogger()
@@ -65,113 +60,588 @@ func main() {
 	log.Info("Loading Module", "module", mod.Name())
 
 	m := &Module{
-		mod: mod,
-		cfg: cfg,
-		log: log,
+		mod:    mod,
+		cfg:    cfg,
+		client: newHTTPClient(cfg),
+		log:    log,
 	}
 
 	if err = m.setup(); err != nil {
 		log.Error("Could not setup module", "error", err.Error())
 		return
 	}
+	if cfg.RefreshOnOnline {
+		m.refreshOnOnline()
+	}
 
-	tick := time.NewTicker(cfg.Interval)
+	tick := time.NewTicker(m.cfg.Interval)
 	defer tick.Stop()
 
 	for {
-		m.update()
+		go m.update()
 
 		<-tick.C
 	}
 }
 
+// theme returns the template of the bundled theme with the given name.
+func theme(name string) ([]byte, error) {
+	return themes.ReadFile("assets/themes/" + name + ".html")
+}
+
+// parseTheme parses the template of the bundled theme with the given
+// name, along with the partials shared by the themes.
+func parseTheme(name string, funcs template.FuncMap) (*template.Template, error) {
+	html, err := theme(name)
+	if err != nil {
+		return nil, fmt.Errorf("loading theme: %w", err)
+	}
+	// The partials are parsed first, so the theme can override their
+	// blocks.
+	tmpl, err := template.New("html").Funcs(funcs).Parse(string(partials))
+	if err != nil {
+		return nil, fmt.Errorf("paring partials: %w", err)
+	}
+	if tmpl, err = tmpl.Parse(string(html)); err != nil {
+		return nil, fmt.Errorf("paring template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// doer performs HTTP requests.
+type doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
 // Module runs the module.
 type Module struct {
-	mod *client.Module
-	cfg Config
+	mod    *client.Module
+	cfg    Config
+	client doer
+
+	provider provider
+	tmpl     *template.Template
+	lastHTML string
+	useEmoji bool
+
+	updating sync.Mutex
+	failures int
+
+	// keyFailures and fallbackSince track the use of the fallback app id,
+	// guarded by the update lock.
+	keyFailures   int
+	fallbackSince time.Time
 
-	tmpl *template.Template
+	mu     sync.Mutex
+	cached responses
+	coords *coordinates
+
+	history  []tempSample
+	daylight daylightLength
+	pressure pressureHistory
+	smoothed smoothedTemp
+
+	cityChecked bool
+	stats       stats
 
 	log *client.Logger
 }
 
 func (m *Module) setup() error {
-	tmpl, err := template.New("html").Parse(string(html))
+	if cfg, ok := m.cfg.withDefaultInterval(); ok {
+		m.log.Info("Using the default interval", "interval", m.cfg.Interval.String(), "default", cfg.Interval.String())
+		m.cfg = cfg
+	}
+	if m.cfg.Interval < minInterval && !m.cfg.AllowFastInterval {
+		m.log.Info("Limiting interval to the minimum", "interval", m.cfg.Interval.String(), "minimum", minInterval.String())
+		m.cfg.Interval = minInterval
+	}
+	if err := m.cfg.Validate(); err != nil {
+		return fmt.Errorf("validating config: %w", err)
+	}
+	if err := m.cfg.checkTiming(); err != nil {
+		if m.cfg.Strict {
+			return fmt.Errorf("validating config: %w", err)
+		}
+		m.log.Info("Requests may overlap updates", "warning", err.Error())
+	}
+
+	tmpl, err := parseTheme(m.cfg.Theme, template.FuncMap{
+		"temp": m.formatTemp,
+	})
 	if err != nil {
-		return fmt.Errorf("paring template: %w", err)
+		return err
 	}
 	m.tmpl = tmpl
 
+	if unknown := unknownMetrics(m.cfg.MetricsOrder); len(unknown) > 0 {
+		m.log.Info("Ignoring unknown metrics", "metrics", strings.Join(unknown, ","))
+	}
+
 	if err = m.mod.LoadCSS(string(css), string(icons)); err != nil {
 		return fmt.Errorf("loading css: %w", err)
 	}
 
-	if err = m.render(data{}); err != nil {
+	m.history = m.loadHistory()
+
+	m.provider = newProvider(m)
+	if n := forecastCount(m.provider, m.cfg.ForecastDays); n <= m.cfg.ForecastDays {
+		m.log.Info("Limiting forecast days to the provider maximum", "days", strconv.Itoa(n-1))
+	}
+	if m.cfg.Lat != nil && m.cfg.Lon != nil {
+		m.coords = &coordinates{Lat: *m.cfg.Lat, Lon: *m.cfg.Lon}
+	} else if m.cfg.LocationName != "" && m.cfg.Provider == "openweathermap" {
+		// WeatherAPI.com resolves the location name itself.
+		if err = m.geocode(); err != nil {
+			m.log.Error("Could not resolve location", "location", m.cfg.LocationName, "error", err.Error())
+		}
+	}
+
+	if m.cfg.DeferFirstRender {
+		return nil
+	}
+	if err = m.render(m.newData()); err != nil {
 		m.log.Error("Could not render weather data", "error", err.Error())
 	}
 	return nil
 }
 
+// Refresh immediately updates the weather outside of the regular
+// interval. It shares the in-flight guard with scheduled updates, so a
+// refresh while an update is running is skipped.
+func (m *Module) Refresh() {
+	m.update()
+}
+
 func (m *Module) update() {
-	d := data{}
-	if err := m.request(apiCurrentPath, url.Values{}, &d.Current); err != nil {
-		m.log.Error("Could not get current weather data", "error", err.Error())
+	if !m.updating.TryLock() {
+		m.log.Info("Skipping update, previous update still running")
+		return
+	}
+	defer m.updating.Unlock()
+
+	d := m.fetch()
+	m.observeKey(d.result)
+	if d.result.primaryFailures > 0 {
+		m.failures++
+	} else {
+		m.failures = 0
 	}
-	if err := m.request(apiForecastPath, url.Values{"cnt": []string{"4"}}, &d.Forecast); err != nil {
-		m.log.Error("Could not get current weather data", "error", err.Error())
+	if m.cfg.MaxStaleFailures > 0 && m.failures >= m.cfg.MaxStaleFailures {
+		d = m.newData()
+		d.Error = "Weather unavailable"
+	}
+	if d.Current.Unix != 0 {
+		d.Current.Yesterday = m.record(d.Current)
+		d.Current.Trend = trend(d.Current, m.formatTemp)
+	}
+	d.Mood = mood(d.Current)
+	d.Anomaly = anomaly(d.Current, m.cfg.SeasonalNormals, m.cfg.AnomalyThreshold)
+	d.Summary = summary(d, m.formatTemp, m.cfg.windUnit())
+	if m.cfg.FeelsLikeBadge {
+		d.Apparent = apparent(d.Current, m.cfg.FeelsLikeThreshold)
+	}
+	if m.cfg.ShowDaylight {
+		d.Daylight = m.recordDaylight(d.Current)
+	}
+	if m.cfg.SunCountdown {
+		d.Countdown = sunCountdown(time.Now(), d.Current)
+	}
+	if m.cfg.ShowPressureTendency {
+		tendency := m.recordPressure(d.Current)
+		for i, mt := range d.Metrics {
+			if mt.Name == "pressure" {
+				d.Metrics[i].Note = tendency
+			}
+		}
+	}
+	if m.cfg.ShowConfidence {
+		age := staleAge + 1
+		if d.Current.Unix != 0 {
+			age = time.Since(time.Unix(d.Current.Unix, 0))
+		}
+		d.Confidence = confidence(d.result, age)
 	}
 
-	if len(d.Forecast.List) > 1 {
-		d.Current.Day = d.Forecast.List[0]
-		d.Forecast.List = d.Forecast.List[1:]
+	if err := m.render(d); err != nil {
+		m.log.Error("Could not render weather data", "error", err.Error())
+	}
+}
+
+func (m *Module) newData() data {
+	d := data{
+		Display: display{
+			IconStyle:         m.iconStyle(),
+			UnknownIcon:       m.cfg.UnknownIcon,
+			Orientation:       m.cfg.Orientation,
+			Accessibility:     m.cfg.Accessibility,
+			CurrentLayout:     m.cfg.currentLayout(),
+			ForecastLayout:    m.cfg.forecastLayout(),
+			CollapseWhenClear: m.cfg.CollapseWhenClear,
+			ShowCurrentIcon:   m.cfg.ShowCurrentIcon,
+			Precision:         m.cfg.precision(),
+			HighlightWeekend:  m.cfg.HighlightWeekend,
+			ForecastTemp:      m.cfg.ForecastTemp,
+			PopFormat:         m.cfg.PopFormat,
+			HideZeroPrecip:    m.cfg.HidePrecipWhenZero,
+			ShowUmbrella:      m.cfg.ShowUmbrella,
+			WindArrow:         m.cfg.WindArrow,
+			Accent:            m.cfg.Accent,
+		},
+	}
+	if m.cfg.Attribution {
+		d.Display.Attribution = m.provider.Name()
 	}
-	d.Current.Icon = d.Current.Weather.Icon()
+	return d
+}
+
+func (m *Module) formatTemp(temp float64) string {
+	return formatTemp(temp, m.cfg.TempPrecision, m.cfg.SignedTemps)
+}
+
+func (m *Module) iconStyle() string {
+	if m.useEmoji {
+		return "emoji"
+	}
+	return m.cfg.IconStyle
+}
+
+func (m *Module) fetch() data {
+	d := m.newData()
+	jobs, err := m.provider.jobs(&d)
+	if err != nil {
+		m.log.Error("Could not get weather data", "error", err.Error())
+		d.result.failures++
+		d.result.primaryFailures++
+		return d
+	}
+	if m.cfg.ShowPollen {
+		if j, ok := m.pollenJob(&d); ok {
+			jobs = append(jobs, j)
+		}
+	}
+	d.result = m.run(jobs)
+	// The lists may share their backing arrays with the cached responses,
+	// which must not be converted again.
+	d.Forecast.List = slices.Clone(d.Forecast.List)
+	d.Hourly.List = slices.Clone(d.Hourly.List)
+	switch m.cfg.ApparentTemp {
+	case "bom":
+		if d.Current.Unix != 0 {
+			units := m.cfg.fetchUnits()
+			temp := convertTemp(d.Current.Main.Temp, units, "metric")
+			wind := convertSpeed(d.Current.Wind.Speed, windUnit(units), "m/s")
+			feelsLike := convertTemp(bomApparent(temp, d.Current.Main.Humidity, wind), "metric", units)
+			d.Current.Main.FeelsLike = &feelsLike
+		}
+	case "off":
+		d.Current.Main.FeelsLike = nil
+	}
+	d.convert(m.cfg.fetchUnits(), m.cfg.tempUnits())
+	d.Current.Wind.Speed = convertSpeed(d.Current.Wind.Speed, windUnit(m.cfg.fetchUnits()), m.cfg.windUnit())
+	d.Current.Main.Pressure = convertPressure(d.Current.Main.Pressure, m.cfg.pressureUnit())
+	d.Current.PressureUnit = m.cfg.pressureUnit()
+	// The temperature is smoothed before anything is derived from it, so
+	// alerts and anomalies match the displayed temperature.
+	if m.cfg.SmoothTemp > 0 && d.Current.Unix != 0 {
+		d.Current.Main.Temp = m.smoothTemp(d.Current, m.cfg.SmoothTemp)
+	}
+
+	// Days are named in the timezone of the location, so a device with
+	// an incorrect clock or timezone does not misname them.
+	now, loc := time.Now(), time.Local
+	if d.Current.Unix != 0 {
+		now, loc = time.Unix(d.Current.Unix, 0), d.Current.Location()
+	}
+	if tz := d.Forecast.City.Timezone; tz != nil {
+		loc = time.FixedZone("", *tz)
+	}
+	m.checkCity(d.Forecast.City)
+	d.Current.Day, d.Forecast.List = d.Forecast.split(now, loc, m.cfg.IncludeToday)
+	hasToday := d.Current.Day.Unix != 0
+	if d.Current.Unix != 0 {
+		d.Current.Observed = time.Unix(d.Current.Unix, 0).In(loc).Format(m.cfg.TimeFormat)
+		if skew := clockSkew(time.Now(), d.Current); skew != 0 {
+			m.log.Info("Device clock differs from weather data", "skew", skew.String())
+		}
+	}
+	d.Current.Weather = d.Current.Weather.override(m.cfg.IconOverrides)
+	d.Current.Icon = d.Current.Weather.Icon(m.cfg.UnknownIcon)
+	d.Current.Emoji = d.Current.Weather.Emoji()
+	d.Current.Wind.Direction = cardinal(d.Current.Wind.Deg, m.cfg.WindPrecision)
+	d.Current.Wind.Unit = m.cfg.windUnit()
+	today := now.In(loc).Format(time.DateOnly)
+	tomorrow := now.In(loc).AddDate(0, 0, 1).Format(time.DateOnly)
 	for i := range d.Forecast.List {
 		dy := d.Forecast.List[i]
 
-		t := time.Unix(dy.Unix, 0)
+		t := time.Unix(dy.Unix, 0).In(loc)
 		dy.Day = t.Format("Monday")
-		dy.Icon = dy.Weather.Icon()
+		dy.IsWeekend = t.Weekday() == time.Saturday || t.Weekday() == time.Sunday
+		switch date := t.Format(time.DateOnly); {
+		case m.cfg.RelativeDays && date == today:
+			dy.Day = cmp.Or(m.cfg.TodayLabel, "Today")
+		case m.cfg.RelativeDays && date == tomorrow:
+			dy.Day = cmp.Or(m.cfg.TomorrowLabel, "Tomorrow")
+		case i == 0 && m.cfg.IncludeToday && hasToday && m.cfg.TodayLabel != "":
+			dy.Day = m.cfg.TodayLabel
+		}
+		dy.Weather = dy.Weather.override(m.cfg.IconOverrides)
+		dy.Icon = dy.Weather.Icon(m.cfg.UnknownIcon)
+		dy.Emoji = dy.Weather.Emoji()
 
 		d.Forecast.List[i] = dy
 	}
+	if m.cfg.RangeBars {
+		rangeBars(d.Forecast.List)
+	}
+	rows := max(m.cfg.ForecastRows, 1)
+	d.Display.RowSize = (len(d.Forecast.List) + rows - 1) / rows
+	for i := 1; i < len(d.Forecast.List); i++ {
+		prev, cur := d.Forecast.List[i-1].Temp.Max, d.Forecast.List[i].Temp.Max
+		switch {
+		case cur > prev:
+			d.Forecast.List[i].Trend = trendUp
+		case cur < prev:
+			d.Forecast.List[i].Trend = trendDown
+		default:
+			d.Forecast.List[i].Trend = trendSame
+		}
+	}
+	if m.cfg.ShowTomorrow {
+		i := 0
+		if m.cfg.IncludeToday && hasToday {
+			i = 1
+		}
+		if i < len(d.Forecast.List) {
+			d.Tomorrow = &d.Forecast.List[i]
+		}
+	}
+	if m.cfg.DynamicBackground {
+		d.Background = background(d.Current.Weather)
+	}
+	if m.cfg.ShowSparkline {
+		d.Sparkline = sparkline(d.Hourly.Temps())
+	}
+	if m.cfg.ShowRainTotal {
+		d.RainTotal = formatRainTotal(d.Hourly.RainTotal(), m.cfg.RainPrecision, m.cfg.displayUnits())
+	}
+	if m.cfg.ShowGoldenHour {
+		d.GoldenHour = d.Current.goldenHour(m.cfg.TimeFormat)
+	}
+	d.Metrics = d.Current.metrics(m.cfg.MetricsOrder, m.formatTemp, m.cfg.precision())
+	if m.cfg.HidePrecipWhenZero && d.Current.Day.Rain <= 0 && d.Current.Day.Pop <= 0 {
+		d.Metrics = slices.DeleteFunc(d.Metrics, func(mt metric) bool {
+			return mt.Name == "rain"
+		})
+	}
+	if m.cfg.ShowComfort {
+		temp := convertTemp(d.Current.Main.Temp, m.cfg.tempUnits(), "metric")
+		for i, mt := range d.Metrics {
+			if mt.Name == "humidity" {
+				d.Metrics[i].Note = comfort(d.Current.Main.Humidity, temp)
+			}
+		}
+	}
+	if d.Current.Unix != 0 {
+		d.Alert = alert(d.Current, m.cfg.FrostThreshold, m.cfg.HeatThreshold)
+	}
+	d.Noteworthy = noteworthy(d, m.cfg.tempUnits())
+	return d
+}
 
-	if err := m.render(d); err != nil {
-		m.log.Error("Could not render weather data", "error", err.Error())
+// job is a single data request.
+type job struct {
+	name string
+	fn   func() error
+	// optional jobs add to the current weather and forecast, which are
+	// displayed without them when they fail.
+	optional bool
+}
+
+// do runs the job, returning a panic, such as from decoding unexpected
+// data, as an error rather than crashing the module.
+func (j job) do() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return j.fn()
+}
+
+// run runs the jobs, with at most the configured concurrency running
+// at once, and waits for them all to complete. A failed job is logged
+// and does not stop the remaining jobs.
+func (m *Module) run(jobs []job) result {
+	sem := make(chan struct{}, max(m.cfg.Concurrency, 1))
+
+	var (
+		mu  sync.Mutex
+		res result
+	)
+	var wg sync.WaitGroup
+	for _, j := range jobs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			start := time.Now()
+			err := j.do()
+			var retries int
+			for ; err != nil && retries < m.cfg.Retries; retries++ {
+				time.Sleep(backoff(retries, m.cfg.RetryDelay, m.cfg.RetryJitter))
+				err = j.do()
+			}
+			m.stats.observe(j.name, time.Since(start), err)
+
+			mu.Lock()
+			res.retries += retries
+			if err != nil {
+				res.failures++
+				if !j.optional {
+					res.primaryFailures++
+				}
+			}
+			// Optional jobs are left out, as they may use another service
+			// or need a subscription the app id does not have.
+			if !j.optional && (errors.Is(err, ErrInvalidAPIKey) || errors.Is(err, ErrRateLimited)) {
+				res.keyFailures++
+			}
+			mu.Unlock()
+
+			if err != nil {
+				m.log.Error("Could not get "+j.name+" weather data", "reason", reason(err), "error", err.Error())
+			}
+		}()
 	}
+	wg.Wait()
+	return res
 }
 
 func (m *Module) render(d data) error {
+	// The data holds no credentials, so it is safe to include in logs
+	// attached to bug reports.
+	if b, err := json.Marshal(d); err == nil {
+		m.log.Debug("Rendering weather data", "data", string(b))
+	}
+
+	out, err := m.Preview(d)
+	if err != nil {
+		return err
+	}
+	// Compare the rendered output, so changes too small to be displayed
+	// do not cause a render.
+	if out == m.lastHTML {
+		return nil
+	}
+	m.mod.Element().SetInnerHTML(out)
+	m.lastHTML = out
+
+	if d.Display.IconStyle == "font" && m.cfg.IconFallback == "emoji" && m.iconsUnavailable() {
+		m.log.Info("Icons could not be displayed, falling back to emoji")
+		m.useEmoji = true
+		d.Display.IconStyle = "emoji"
+		return m.render(d)
+	}
+	return nil
+}
+
+// Preview returns the HTML rendered for the data, without displaying it.
+func (m *Module) Preview(d data) (string, error) {
 	var buf bytes.Buffer
 	if err := m.tmpl.Execute(&buf, d); err != nil {
-		return fmt.Errorf("rendering html: %w", err)
+		return "", fmt.Errorf("rendering html: %w", err)
 	}
-	m.mod.Element().SetInnerHTML(buf.String())
-	return nil
+	return buf.String(), nil
 }
 
-func (m *Module) request(p string, qry url.Values, v interface{}) error {
-	u, err := url.Parse(api + p)
+// iconsUnavailable reports whether the rendered icons have no styles
+// applied, which happens when loading the icon styles failed.
+func (m *Module) iconsUnavailable() bool {
+	el := m.mod.Element().QuerySelector(".wu")
+	if el == nil {
+		return false
+	}
+	bg := dom.GetWindow().GetComputedStyle(el, "").GetPropertyValue("background-image")
+	return bg == "" || bg == "none"
+}
+
+// cached stores v in cache when the request was successful. When the
+// request failed, v is set from the cache instead, so the last data is
+// displayed, and the error is returned.
+func cached[T any](err error, v, cache *T) error {
 	if err != nil {
-		return fmt.Errorf("could not parse url: %w", err)
+		*v = *cache
+		return err
 	}
+	*cache = *v
+	return nil
+}
+
+func (m *Module) request(p string, qry url.Values, v interface{}) error {
 	q := url.Values{}
-	q.Set("id", m.cfg.LocationID)
-	q.Set("appid", m.cfg.AppID)
-	q.Set("units", m.cfg.Units)
+	if c := m.location(); c != nil {
+		q.Set("lat", strconv.FormatFloat(c.Lat, 'f', -1, 64))
+		q.Set("lon", strconv.FormatFloat(c.Lon, 'f', -1, 64))
+	} else {
+		q.Set("id", m.cfg.LocationID)
+	}
+	q.Set("appid", m.appID())
+	q.Set("units", m.cfg.fetchUnits())
 	for k, val := range qry {
 		q[k] = val
 	}
+	addParams(q, m.cfg.ExtraParams)
+
+	// The base url can include a path, with or without a trailing slash.
+	u, err := url.JoinPath(m.cfg.baseURL(), p)
+	if err != nil {
+		return fmt.Errorf("could not parse url: %w", err)
+	}
+	return m.get(u, q, v)
+}
+
+func (m *Module) get(rawURL string, q url.Values, v interface{}) error {
+	return m.send(http.MethodGet, rawURL, q, nil, v)
+}
+
+// send requests the url with the given method, decoding the response
+// into v. When body is not nil, it is sent encoded as JSON.
+func (m *Module) send(method, rawURL string, q url.Values, body, v interface{}) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("could not parse url: %w", err)
+	}
 	u.RawQuery = q.Encode()
 
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("could not encode body: %w", err)
+		}
+		r = bytes.NewReader(b)
+	}
+
 	//nolint:noctx
-	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	req, err := http.NewRequest(method, u.String(), r)
 	if err != nil {
 		return fmt.Errorf("could create request: %w", err)
 	}
-	resp, err := http.DefaultClient.Do(req)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if !m.cfg.FollowRedirects {
+		req.Header.Set(fetchRedirectHeader, "error")
+	}
+	resp, err := m.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("could not request url: %w", err)
+		return fmt.Errorf("could not request url: %w: %w", ErrNetwork, err)
 	}
 	defer func() {
 		_, _ = io.Copy(io.Discard, resp.Body)
@@ -181,52 +651,315 @@ func (m *Module) request(p string, qry url.Values, v interface{}) error {
 	if resp.StatusCode != http.StatusOK {
 		de := dataError{}
 		if err = json.NewDecoder(resp.Body).Decode(&de); err != nil {
-			return fmt.Errorf("could not parse error: %w", err)
+			// The error is not from the API, so report the status instead.
+			return apiError(resp.StatusCode, "", resp.Status)
 		}
-		return fmt.Errorf("could not fetch data: %s", de.Message)
+		return apiError(resp.StatusCode, de.Code, de.message())
+	}
+
+	// Captive portals and proxies can respond with a page instead.
+	if ct := resp.Header.Get("Content-Type"); !isJSON(ct) {
+		return fmt.Errorf("could not parse data: %w %q (likely captive portal)", ErrContentType, ct)
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("could not read data: %w: %w", ErrNetwork, err)
+	}
+	// The API can report errors with a successful status code.
+	de := dataError{}
+	if err = json.Unmarshal(b, &de); err == nil && de.Code != "" && de.Code != "200" {
+		return apiError(resp.StatusCode, de.Code, de.message())
 	}
 
-	if err = json.NewDecoder(resp.Body).Decode(v); err != nil {
-		return fmt.Errorf("could not parse data: %w", err)
+	if err = json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("could not parse data: %w: %w", ErrDecode, err)
 	}
 	return nil
 }
 
+// responses are the last successful responses, reused when a request
+// fails.
+type responses struct {
+	Current    current
+	Forecast   forecast
+	Hourly     hourly
+	Nowcast    nowcast
+	OneCall    oneCallResponse
+	WeatherAPI weatherAPIResponse
+	Pollen     pollenResponse
+}
+
 type dataError struct {
-	Code    int    `json:"cod"`
+	Code    code   `json:"cod"`
 	Message string `json:"message"`
+	Error   struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (e dataError) message() string {
+	if e.Message == "" {
+		return e.Error.Message
+	}
+	return e.Message
+}
+
+// code is a response code, encoded as either a number or a string.
+type code string
+
+// UnmarshalJSON unmarshals a code from a number or a string.
+func (c *code) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*c = code(s)
+		return nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err != nil {
+		return err
+	}
+	*c = code(n)
+	return nil
+}
+
+// number is a number, encoded as either a number or a string.
+type number float64
+
+// UnmarshalJSON unmarshals a number from a number or a string.
+func (n *number) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		var f float64
+		if err = json.Unmarshal(b, &f); err != nil {
+			return err
+		}
+		*n = number(f)
+		return nil
+	}
+
+	s = strings.TrimSpace(s)
+	if s == "" {
+		*n = 0
+		return nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return fmt.Errorf("parsing number %q: %w", s, err)
+	}
+	*n = number(f)
+	return nil
 }
 
 type data struct {
-	Current  current
-	Forecast forecast
+	Display    display
+	Current    current
+	Forecast   forecast
+	Nowcast    nowcast
+	Hourly     hourly
+	Sparkline  string
+	RainTotal  string
+	Metrics    []metric
+	Tomorrow   *day
+	GoldenHour *goldenHour
+	Daylight   *daylight
+	Countdown  string
+	Background string
+	Mood       string
+	Anomaly    string
+	Apparent   string
+	Summary    string
+	Pollen     string
+	Alert      string
+	Noteworthy bool
+	Confidence string
+	Error      string
+
+	result result
+}
+
+type display struct {
+	IconStyle         string
+	UnknownIcon       string
+	Orientation       string
+	Accessibility     bool
+	CurrentLayout     string
+	ForecastLayout    string
+	CollapseWhenClear bool
+	Attribution       string
+	RowSize           int
+	ShowCurrentIcon   bool
+	Precision         precision
+	HighlightWeekend  bool
+	ForecastTemp      string
+	PopFormat         string
+	HideZeroPrecip    bool
+	ShowUmbrella      bool
+	WindArrow         bool
+	Accent            string
 }
 
 type current struct {
-	Main struct {
-		Temp float64 `json:"temp"`
+	Unix     int64 `json:"dt"`
+	Timezone int   `json:"timezone"`
+	Observed string
+	Main     struct {
+		Temp      float64  `json:"temp"`
+		FeelsLike *float64 `json:"feels_like"`
+		Humidity  float64  `json:"humidity"`
+		Pressure  float64  `json:"pressure"`
 	} `json:"main"`
+	PressureUnit string
+	Wind         wind `json:"wind"`
+	Clouds       struct {
+		All float64 `json:"all"`
+	} `json:"clouds"`
+	Sys struct {
+		Sunrise int64 `json:"sunrise"`
+		Sunset  int64 `json:"sunset"`
+	} `json:"sys"`
 	Day     day
 	Weather weather `json:"weather"`
 	Icon    string
+	Emoji   string
+
+	Yesterday *float64
+	Trend     string
+}
+
+// Location returns the time zone of the location.
+func (c current) Location() *time.Location {
+	return time.FixedZone("", c.Timezone)
+}
+
+type wind struct {
+	Speed     float64 `json:"speed"`
+	Deg       float64 `json:"deg"`
+	Direction string
+	Unit      string
+}
+
+// Days returns the forecast days to display.
+func (d data) Days() []day {
+	return d.Forecast.List
+}
+
+// Rows returns the forecast days to display split into rows of the row
+// size, with any remaining days in the last row.
+func (d data) Rows() [][]day {
+	days, size := d.Days(), d.Display.RowSize
+	if size <= 0 || size >= len(days) {
+		return [][]day{days}
+	}
+
+	rows := make([][]day, 0, (len(days)+size-1)/size)
+	for len(days) > size {
+		rows = append(rows, days[:size])
+		days = days[size:]
+	}
+	return append(rows, days)
 }
 
 type forecast struct {
+	City city  `json:"city"`
 	List []day `json:"list"`
 }
 
+// city is the location a forecast is for.
+type city struct {
+	Name     string `json:"name"`
+	Timezone *int   `json:"timezone"`
+	Coord    struct {
+		Lat float64 `json:"lat"`
+		Lon float64 `json:"lon"`
+	} `json:"coord"`
+}
+
+// split splits the forecast into today and the days to display, which
+// only include today if requested. Days before today, such as from an
+// older cached response, are dropped, and when the forecast does not
+// start today there is no today.
+func (f forecast) split(now time.Time, loc *time.Location, includeToday bool) (day, []day) {
+	today := now.In(loc).Format(time.DateOnly)
+	date := func(d day) string {
+		return time.Unix(d.Unix, 0).In(loc).Format(time.DateOnly)
+	}
+
+	list := f.List
+	for len(list) > 0 && date(list[0]) < today {
+		list = list[1:]
+	}
+	switch {
+	case len(list) == 0:
+		return day{}, nil
+	case date(list[0]) != today:
+		return day{}, list
+	case includeToday:
+		return list[0], list
+	default:
+		return list[0], list[1:]
+	}
+}
+
 type day struct {
-	Unix int64 `json:"dt"`
-	Day  string
-	Temp struct {
-		Min float64 `json:"min"`
-		Max float64 `json:"max"`
-	} `json:"temp"`
-	Weather weather `json:"weather"`
-	Icon    string
-	Rain    float64 `json:"rain"`
+	Unix      int64 `json:"dt"`
+	Day       string
+	Temp      dayTemp    `json:"temp"`
+	FeelsLike *feelsLike `json:"feels_like"`
+	Weather   weather    `json:"weather"`
+	Icon      string
+	Emoji     string
+	Rain      number `json:"rain"`
+	Pop       number `json:"pop"`
+	Trend     string
+	Bar       *rangeBar
+	IsWeekend bool
 }
 
+type dayTemp struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Morn  float64 `json:"morn"`
+	Day   float64 `json:"day"`
+	Eve   float64 `json:"eve"`
+	Night float64 `json:"night"`
+}
+
+// Value returns the temperature of the given part of the day, or the
+// maximum for any other value.
+func (t dayTemp) Value(field string) float64 {
+	switch field {
+	case "morn":
+		return t.Morn
+	case "day":
+		return t.Day
+	case "eve":
+		return t.Eve
+	case "night":
+		return t.Night
+	default:
+		return t.Max
+	}
+}
+
+type feelsLike struct {
+	Morn  float64 `json:"morn"`
+	Day   float64 `json:"day"`
+	Eve   float64 `json:"eve"`
+	Night float64 `json:"night"`
+}
+
+// Trends of a forecast day relative to the previous day.
+const (
+	trendUp   = "up"
+	trendDown = "down"
+	trendSame = "same"
+)
+
 const unknownIcon = "wu-unknown"
 
 var iconTable = map[string]string{
@@ -250,18 +983,70 @@ var iconTable = map[string]string{
 	"50n": "wu-fog wu-night",
 }
 
+const unknownEmoji = "❔"
+
+var emojiTable = map[string]string{
+	"01d": "☀️",
+	"02d": "⛅",
+	"03d": "☁️",
+	"04d": "☁️",
+	"09d": "🌧️",
+	"10d": "🌦️",
+	"11d": "⛈️",
+	"13d": "❄️",
+	"50d": "🌫️",
+	"01n": "🌙",
+	"02n": "☁️",
+	"03n": "☁️",
+	"04n": "☁️",
+	"09n": "🌧️",
+	"10n": "🌧️",
+	"11n": "⛈️",
+	"13n": "❄️",
+	"50n": "🌫️",
+}
+
 type weather []struct {
-	IconCode string `json:"icon"`
+	IconCode    string `json:"icon"`
+	Description string `json:"description"`
 }
 
-// Icon returns the weather icon or the unknown icon.
-func (w weather) Icon() string {
+// Description returns the weather description.
+func (w weather) Description() string {
 	if len(w) == 0 {
-		return unknownIcon
+		return ""
+	}
+	return w[0].Description
+}
+
+// Icon returns the weather icon or the given unknown icon.
+func (w weather) Icon(unknown string) string {
+	if len(w) == 0 {
+		return unknown
 	}
 	icn, ok := iconTable[w[0].IconCode]
 	if !ok {
-		return unknownIcon
+		return unknown
+	}
+	return icn
+}
+
+// code returns the weather condition code, without the time of day.
+func (w weather) code() string {
+	if len(w) == 0 || len(w[0].IconCode) < 2 {
+		return ""
+	}
+	return w[0].IconCode[:2]
+}
+
+// Emoji returns the weather emoji or the unknown emoji.
+func (w weather) Emoji() string {
+	if len(w) == 0 {
+		return unknownEmoji
+	}
+	icn, ok := emojiTable[w[0].IconCode]
+	if !ok {
+		return unknownEmoji
 	}
 	return icn
 }
@@ -0,0 +1,59 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+// metricNames are the names of the available current metrics.
+var metricNames = []string{"max", "min", "rain", "wind", "humidity", "pressure"}
+
+// metric is a current metric for display.
+type metric struct {
+	Name  string
+	Label string
+	Value string
+	Unit  string
+	Note  string
+}
+
+// metrics returns the current metrics in the given order, ignoring any
+// unknown names. Temperatures are formatted with temp, and rain and wind
+// with their precision.
+func (c current) metrics(order []string, temp func(float64) string, prec precision) []metric {
+	ms := make([]metric, 0, len(order))
+	for _, name := range order {
+		m := metric{Name: name}
+		switch name {
+		case "max":
+			m.Label, m.Value, m.Unit = "Max", temp(c.Day.Temp.Max), "°"
+		case "min":
+			m.Label, m.Value, m.Unit = "Min", temp(c.Day.Temp.Min), "°"
+		case "rain":
+			m.Label, m.Value, m.Unit = "Rain", formatNumber(float64(c.Day.Rain), prec.Rain), "mm"
+		case "wind":
+			m.Label, m.Value, m.Unit = "Wind", formatNumber(c.Wind.Speed, prec.Wind), c.Wind.Unit+" "+c.Wind.Direction
+		case "humidity":
+			m.Label, m.Value, m.Unit = "Hum", fmt.Sprintf("%.f", c.Main.Humidity), "%"
+		case "pressure":
+			m.Label, m.Value, m.Unit = "Pres", formatPressure(c.Main.Pressure, c.PressureUnit), c.PressureUnit
+		default:
+			continue
+		}
+		ms = append(ms, m)
+	}
+	return ms
+}
+
+// unknownMetrics returns the names in order that are not known metrics.
+func unknownMetrics(order []string) []string {
+	var unknown []string
+	for _, name := range order {
+		if !slices.Contains(metricNames, name) {
+			unknown = append(unknown, name)
+		}
+	}
+	return unknown
+}
@@ -0,0 +1,63 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+const oneCallAPI = "https://api.openweathermap.org/data/3.0/onecall"
+
+type nowcast struct {
+	Minutely []struct {
+		Unix          int64   `json:"dt"`
+		Precipitation float64 `json:"precipitation"`
+	} `json:"minutely"`
+}
+
+// Summary returns when rain starts or stops within the next hour, or an
+// empty string when no rain is expected.
+func (n nowcast) Summary() string {
+	if len(n.Minutely) == 0 {
+		return ""
+	}
+
+	start := n.Minutely[0].Unix
+	raining := n.Minutely[0].Precipitation > 0
+	for _, m := range n.Minutely[1:] {
+		if (m.Precipitation > 0) == raining {
+			continue
+		}
+
+		mins := (m.Unix - start) / 60
+		if raining {
+			return fmt.Sprintf("Rain stopping in %d min", mins)
+		}
+		return fmt.Sprintf("Rain starting in %d min", mins)
+	}
+	if raining {
+		return "Rain for the next hour"
+	}
+	return ""
+}
+
+// oneCall requests the One Call API for the resolved coordinates.
+func (m *Module) oneCall(qry url.Values, v interface{}) error {
+	c := m.location()
+	if c == nil {
+		return fmt.Errorf("coordinates are required")
+	}
+
+	q := url.Values{}
+	q.Set("lat", strconv.FormatFloat(c.Lat, 'f', -1, 64))
+	q.Set("lon", strconv.FormatFloat(c.Lon, 'f', -1, 64))
+	q.Set("appid", m.appID())
+	q.Set("units", m.cfg.fetchUnits())
+	for k, val := range qry {
+		q[k] = val
+	}
+	addParams(q, m.cfg.ExtraParams)
+	return m.get(oneCallAPI, q, v)
+}
@@ -0,0 +1,92 @@
+//go:build js && wasm
+
+package main
+
+import "net/url"
+
+// oneCallResponse is a One Call API response, holding the current
+// weather and forecasts of a single request. Any section can be missing
+// when it is excluded.
+type oneCallResponse struct {
+	Timezone int `json:"timezone_offset"`
+	Current  *struct {
+		Unix      int64   `json:"dt"`
+		Temp      float64 `json:"temp"`
+		FeelsLike float64 `json:"feels_like"`
+		Humidity  float64 `json:"humidity"`
+		Pressure  float64 `json:"pressure"`
+		WindSpeed float64 `json:"wind_speed"`
+		WindDeg   float64 `json:"wind_deg"`
+		Clouds    float64 `json:"clouds"`
+		Sunrise   int64   `json:"sunrise"`
+		Sunset    int64   `json:"sunset"`
+		Weather   weather `json:"weather"`
+	} `json:"current"`
+	nowcast
+
+	Hourly []struct {
+		Unix int64   `json:"dt"`
+		Temp float64 `json:"temp"`
+		Rain struct {
+			OneHour number `json:"1h"`
+		} `json:"rain"`
+	} `json:"hourly"`
+	Daily []day `json:"daily"`
+}
+
+// oneCallJob returns the job requesting all the data in a single One
+// Call request, excluding the parts that are not displayed.
+func (p openWeatherMap) oneCallJob(d *data) job {
+	m := p.m
+
+	exclude := "alerts"
+	if !m.cfg.ShowNowcast {
+		exclude += ",minutely"
+	}
+	if !m.cfg.needsHourly() {
+		exclude += ",hourly"
+	}
+	return job{name: "onecall", fn: func() error {
+		var resp oneCallResponse
+		err := m.oneCall(url.Values{"exclude": []string{exclude}}, &resp)
+		err = cached(err, &resp, &m.cached.OneCall)
+		resp.fill(d, forecastCount(p, m.cfg.ForecastDays))
+		return err
+	}}
+}
+
+// fill distributes the response into the data, keeping at most the
+// given number of days. Missing sections are left empty.
+func (r oneCallResponse) fill(d *data, days int) {
+	d.Current.Timezone = r.Timezone
+	if cur := r.Current; cur != nil {
+		d.Current.Unix = cur.Unix
+		d.Current.Main.Temp = cur.Temp
+		feelsLike := cur.FeelsLike
+		d.Current.Main.FeelsLike = &feelsLike
+		d.Current.Main.Humidity = cur.Humidity
+		d.Current.Main.Pressure = cur.Pressure
+		d.Current.Wind.Speed = cur.WindSpeed
+		d.Current.Wind.Deg = cur.WindDeg
+		d.Current.Clouds.All = cur.Clouds
+		d.Current.Sys.Sunrise = cur.Sunrise
+		d.Current.Sys.Sunset = cur.Sunset
+		d.Current.Weather = cur.Weather
+	}
+
+	d.Forecast.List = r.Daily[:min(days, len(r.Daily))]
+	d.Nowcast = r.nowcast
+
+	// Hourly forecasts are sampled every 3 hours to match the 3 hour
+	// forecast, with the rain of the 3 hours combined.
+	d.Hourly.List = nil
+	for i := 0; i < len(r.Hourly) && len(d.Hourly.List) < hourlyCount; i += 3 {
+		var h hour
+		h.Unix = r.Hourly[i].Unix
+		h.Main.Temp = r.Hourly[i].Temp
+		for _, hr := range r.Hourly[i:min(i+3, len(r.Hourly))] {
+			h.Rain.ThreeHour += hr.Rain.OneHour
+		}
+		d.Hourly.List = append(d.Hourly.List, h)
+	}
+}
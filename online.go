@@ -0,0 +1,45 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"sync"
+	"time"
+
+	"honnef.co/go/js/dom/v2"
+)
+
+// onlineDebounce is how long the network must stay online before
+// refreshing, so a flapping connection does not cause a refresh each
+// time it reconnects.
+const onlineDebounce = 5 * time.Second
+
+// refreshOnOnline refreshes the weather when the browser reports the
+// network is back online.
+func (m *Module) refreshOnOnline() {
+	var (
+		mu    sync.Mutex
+		timer *time.Timer
+	)
+	dom.GetWindow().AddEventListener("online", false, func(dom.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if timer != nil {
+			timer.Stop()
+		}
+		timer = time.AfterFunc(onlineDebounce, func() {
+			m.log.Info("Network online, refreshing weather")
+			m.Refresh()
+		})
+	})
+	// Going offline again cancels a pending refresh.
+	dom.GetWindow().AddEventListener("offline", false, func(dom.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if timer != nil {
+			timer.Stop()
+		}
+	})
+}
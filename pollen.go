@@ -0,0 +1,84 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// pollenAPI provides pollen forecasts, as neither weather service
+// offers them. Pollen is only forecast for Europe.
+const pollenAPI = "https://air-quality-api.open-meteo.com/v1/air-quality"
+
+// Pollen levels.
+const (
+	pollenLow    = "low"
+	pollenMedium = "medium"
+	pollenHigh   = "high"
+)
+
+// pollenResponse is the current pollen concentration of each pollen,
+// which is null where pollen is not forecast.
+type pollenResponse struct {
+	Current struct {
+		Alder   *float64 `json:"alder_pollen"`
+		Birch   *float64 `json:"birch_pollen"`
+		Olive   *float64 `json:"olive_pollen"`
+		Grass   *float64 `json:"grass_pollen"`
+		Mugwort *float64 `json:"mugwort_pollen"`
+		Ragweed *float64 `json:"ragweed_pollen"`
+	} `json:"current"`
+}
+
+// level returns the highest level of any pollen, or an empty string when
+// no pollen is forecast. Medium and high levels are reached at
+// concentrations, in grains per cubic metre, that differ for trees,
+// grasses and weeds.
+func (r pollenResponse) level() string {
+	c := r.Current
+	var lvl string
+	for _, p := range []struct {
+		conc         *float64
+		medium, high float64
+	}{
+		{c.Alder, 15, 90},
+		{c.Birch, 15, 90},
+		{c.Olive, 15, 90},
+		{c.Grass, 20, 50},
+		{c.Mugwort, 10, 50},
+		{c.Ragweed, 10, 50},
+	} {
+		switch {
+		case p.conc == nil:
+		case *p.conc >= p.high:
+			return pollenHigh
+		case *p.conc >= p.medium:
+			lvl = pollenMedium
+		case lvl == "":
+			lvl = pollenLow
+		}
+	}
+	return lvl
+}
+
+// pollenJob returns the job requesting the pollen level, which requires
+// the coordinates of the location.
+func (m *Module) pollenJob(d *data) (job, bool) {
+	c := m.location()
+	if c == nil {
+		return job{}, false
+	}
+
+	q := url.Values{}
+	q.Set("latitude", strconv.FormatFloat(c.Lat, 'f', -1, 64))
+	q.Set("longitude", strconv.FormatFloat(c.Lon, 'f', -1, 64))
+	q.Set("current", "alder_pollen,birch_pollen,olive_pollen,grass_pollen,mugwort_pollen,ragweed_pollen")
+	return job{name: "pollen", fn: func() error {
+		var resp pollenResponse
+		err := m.get(pollenAPI, q, &resp)
+		err = cached(err, &resp, &m.cached.Pollen)
+		d.Pollen = resp.level()
+		return err
+	}, optional: true}, true
+}
@@ -0,0 +1,74 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"math"
+	"time"
+)
+
+// pressurePeriod is the period the tendency is conventionally measured
+// over.
+const pressurePeriod = 3 * time.Hour
+
+// pressureReading is a pressure observation in hectopascals.
+type pressureReading struct {
+	At       time.Time
+	Pressure float64
+}
+
+// pressureHistory is the pressure observed over the last period, recorded
+// to compute the barometric tendency.
+type pressureHistory struct {
+	readings []pressureReading
+	tendency string
+}
+
+// recordPressure stores the current pressure and returns the tendency
+// over the last 3 hours, once that long has been observed. The tendency
+// is kept while the observation has not changed.
+func (m *Module) recordPressure(c current) string {
+	if c.Unix == 0 {
+		return ""
+	}
+
+	at := time.Unix(c.Unix, 0)
+	hPa := c.Main.Pressure * pressureUnits[c.PressureUnit]
+	h := &m.pressure
+	if n := len(h.readings); n > 0 && !at.After(h.readings[n-1].At) {
+		return h.tendency
+	}
+	h.readings = append(h.readings, pressureReading{At: at, Pressure: hPa})
+
+	// Keep the newest reading at least a period old to compare against,
+	// rather than extrapolating from shorter changes, which are dominated
+	// by the rounding of the reported pressure.
+	for len(h.readings) > 1 && at.Sub(h.readings[1].At) >= pressurePeriod {
+		h.readings = h.readings[1:]
+	}
+	h.tendency = ""
+	if oldest := h.readings[0]; at.Sub(oldest.At) >= pressurePeriod {
+		h.tendency = pressureTendency(hPa - oldest.Pressure)
+	}
+	return h.tendency
+}
+
+// pressureTendency returns the tendency described by a pressure change
+// in hectopascals over 3 hours.
+func pressureTendency(change float64) string {
+	dir := "rising"
+	if change < 0 {
+		dir = "falling"
+	}
+
+	switch abs := math.Abs(change); {
+	case abs < 0.1:
+		return "steady"
+	case abs < 1.6:
+		return dir + " slowly"
+	case abs < 3.6:
+		return dir
+	default:
+		return dir + " rapidly"
+	}
+}
@@ -0,0 +1,105 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+)
+
+// provider provides weather data from a weather service.
+type provider interface {
+	// Name returns the name of the weather service.
+	Name() string
+	// maxDays returns the most forecast days, including today, that can
+	// be requested.
+	maxDays() int
+	// jobs returns the requests that fill in the data.
+	jobs(d *data) ([]job, error)
+}
+
+// protectedParams are the query parameters holding credentials or the
+// location, which extra parameters cannot override.
+var protectedParams = []string{"appid", "key", "id", "lat", "lon", "q"}
+
+// addParams sets the extra parameters in the query, except for the
+// protected parameters.
+func addParams(q url.Values, extra map[string]string) {
+	for k, v := range extra {
+		if slices.Contains(protectedParams, k) {
+			continue
+		}
+		q.Set(k, v)
+	}
+}
+
+// forecastCount returns the number of forecast days, including today,
+// to request, limited to the maximum of the provider.
+func forecastCount(p provider, days int) int {
+	return min(days+1, p.maxDays())
+}
+
+func newProvider(m *Module) provider {
+	switch m.cfg.Provider {
+	case "weatherapi":
+		return weatherAPI{m: m}
+	default:
+		return openWeatherMap{m: m}
+	}
+}
+
+// openWeatherMap provides weather data from OpenWeatherMap.
+type openWeatherMap struct {
+	m *Module
+}
+
+func (p openWeatherMap) Name() string {
+	return "OpenWeatherMap"
+}
+
+// owmMaxDays is the maximum count of the daily forecast endpoint.
+const owmMaxDays = 16
+
+func (p openWeatherMap) maxDays() int {
+	return owmMaxDays
+}
+
+func (p openWeatherMap) jobs(d *data) ([]job, error) {
+	m := p.m
+	if m.location() == nil && m.cfg.LocationName != "" {
+		if err := m.geocode(); err != nil {
+			return nil, fmt.Errorf("resolving location %q: %w", m.cfg.LocationName, err)
+		}
+	}
+
+	if m.cfg.OneCall {
+		return []job{p.oneCallJob(d)}, nil
+	}
+
+	jobs := []job{
+		{name: "current", fn: func() error {
+			err := m.request(apiCurrentPath, url.Values{}, &d.Current)
+			return cached(err, &d.Current, &m.cached.Current)
+		}},
+		{name: "forecast", fn: func() error {
+			cnt := strconv.Itoa(forecastCount(p, m.cfg.ForecastDays))
+			err := m.request(apiForecastPath, url.Values{"cnt": []string{cnt}}, &d.Forecast)
+			return cached(err, &d.Forecast, &m.cached.Forecast)
+		}},
+	}
+	if m.cfg.needsHourly() {
+		jobs = append(jobs, job{name: "hourly", fn: func() error {
+			err := m.request(apiHourlyPath, url.Values{"cnt": []string{strconv.Itoa(hourlyCount)}}, &d.Hourly)
+			return cached(err, &d.Hourly, &m.cached.Hourly)
+		}, optional: true})
+	}
+	if m.cfg.ShowNowcast {
+		jobs = append(jobs, job{name: "nowcast", fn: func() error {
+			err := m.oneCall(url.Values{"exclude": []string{"current,hourly,daily,alerts"}}, &d.Nowcast)
+			return cached(err, &d.Nowcast, &m.cached.Nowcast)
+		}, optional: true})
+	}
+	return jobs, nil
+}
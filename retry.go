@@ -0,0 +1,32 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// maxBackoff is the longest delay between retries.
+const maxBackoff = time.Minute
+
+// backoff returns the delay before the given retry, starting from zero,
+// doubling the base delay for each retry with the jitter applied.
+func backoff(retry int, base time.Duration, jitter string) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+	d := maxBackoff
+	if shifted := base << retry; retry < 32 && shifted > 0 {
+		d = min(shifted, maxBackoff)
+	}
+
+	switch jitter {
+	case "full":
+		return rand.N(d + 1)
+	case "equal":
+		return d/2 + rand.N(d/2+1)
+	default:
+		return d
+	}
+}
@@ -0,0 +1,92 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+const apiHourlyPath = "forecast"
+
+// hourlyCount is the number of 3 hour forecasts in the sparkline.
+const hourlyCount = 8
+
+// Sparkline dimensions, in view box units.
+const (
+	sparklineWidth  = 100
+	sparklineHeight = 20
+)
+
+type hourly struct {
+	List []hour `json:"list"`
+}
+
+type hour struct {
+	Unix int64 `json:"dt"`
+	Main struct {
+		Temp float64 `json:"temp"`
+	} `json:"main"`
+	Rain struct {
+		ThreeHour number `json:"3h"`
+	} `json:"rain"`
+}
+
+// Temps returns the forecast temperatures.
+func (h hourly) Temps() []float64 {
+	temps := make([]float64, 0, len(h.List))
+	for _, e := range h.List {
+		temps = append(temps, e.Main.Temp)
+	}
+	return temps
+}
+
+// RainTotal returns the total rain expected over the forecast in
+// millimetres.
+func (h hourly) RainTotal() float64 {
+	var mm float64
+	for _, e := range h.List {
+		mm += float64(e.Rain.ThreeHour)
+	}
+	return mm
+}
+
+// normalize scales the values to fit between zero and the given height,
+// with the lowest value at the height. When all values are equal, they
+// are placed in the middle.
+func normalize(vals []float64, height float64) []float64 {
+	if len(vals) == 0 {
+		return nil
+	}
+
+	lo, hi := vals[0], vals[0]
+	for _, v := range vals[1:] {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+
+	out := make([]float64, len(vals))
+	for i, v := range vals {
+		if hi == lo {
+			out[i] = height / 2
+			continue
+		}
+		out[i] = height - (v-lo)/(hi-lo)*height
+	}
+	return out
+}
+
+// sparkline returns the SVG polyline points of the values, or an empty
+// string when there are too few values to draw a line.
+func sparkline(vals []float64) string {
+	if len(vals) < 2 {
+		return ""
+	}
+
+	step := float64(sparklineWidth) / float64(len(vals)-1)
+	points := make([]string, 0, len(vals))
+	for i, y := range normalize(vals, sparklineHeight) {
+		x := strconv.FormatFloat(float64(i)*step, 'f', 1, 64)
+		points = append(points, x+","+strconv.FormatFloat(y, 'f', 1, 64))
+	}
+	return strings.Join(points, " ")
+}
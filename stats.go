@@ -0,0 +1,77 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// stats tracks request statistics per endpoint.
+type stats struct {
+	mu         sync.Mutex
+	success    map[string]uint64
+	failure    map[string]uint64
+	duration   map[string]time.Duration
+	lastUpdate time.Time
+}
+
+func (s *stats) observe(endpoint string, dur time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.success == nil {
+		s.success = map[string]uint64{}
+		s.failure = map[string]uint64{}
+		s.duration = map[string]time.Duration{}
+	}
+	if err != nil {
+		s.failure[endpoint]++
+	} else {
+		s.success[endpoint]++
+		s.lastUpdate = time.Now()
+	}
+	s.duration[endpoint] = dur
+}
+
+// PrometheusMetrics returns the request statistics in the Prometheus
+// text exposition format, for the host to expose.
+func (m *Module) PrometheusMetrics() string {
+	s := &m.stats
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	endpoints := make([]string, 0, len(s.duration))
+	for e := range s.duration {
+		endpoints = append(endpoints, e)
+	}
+	slices.Sort(endpoints)
+
+	var sb strings.Builder
+	writeMetric := func(name, typ, help string, val func(endpoint string) string) {
+		_, _ = fmt.Fprintf(&sb, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+		for _, e := range endpoints {
+			_, _ = fmt.Fprintf(&sb, "%s{endpoint=%q} %s\n", name, e, val(e))
+		}
+	}
+	writeMetric("weather_fetch_success_total", "counter", "Total successful weather data requests.", func(e string) string {
+		return fmt.Sprint(s.success[e])
+	})
+	writeMetric("weather_fetch_failure_total", "counter", "Total failed weather data requests.", func(e string) string {
+		return fmt.Sprint(s.failure[e])
+	})
+	writeMetric("weather_fetch_duration_seconds", "gauge", "Duration of the last weather data request.", func(e string) string {
+		return fmt.Sprint(s.duration[e].Seconds())
+	})
+
+	var last int64
+	if !s.lastUpdate.IsZero() {
+		last = s.lastUpdate.Unix()
+	}
+	_, _ = fmt.Fprintf(&sb, "# HELP weather_last_update_timestamp_seconds Time of the last successful request.\n"+
+		"# TYPE weather_last_update_timestamp_seconds gauge\nweather_last_update_timestamp_seconds %d\n", last)
+	return sb.String()
+}
@@ -0,0 +1,56 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"syscall/js"
+)
+
+// loadLocal decodes the value kept in local storage under the key into
+// v, reporting whether a value was loaded. A missing or corrupt value is
+// ignored.
+func (m *Module) loadLocal(key string, v any) (ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			m.log.Info("Could not read local storage", "key", key, "error", fmt.Sprint(r))
+			ok = false
+		}
+	}()
+
+	s := js.Global().Get("localStorage")
+	if !s.Truthy() {
+		return false
+	}
+	item := s.Call("getItem", key)
+	if item.Type() != js.TypeString {
+		return false
+	}
+
+	if err := json.Unmarshal([]byte(item.String()), v); err != nil {
+		m.log.Info("Ignoring corrupt local storage", "key", key, "error", err.Error())
+		return false
+	}
+	return true
+}
+
+// storeLocal keeps the value in local storage under the key, replacing
+// any previous value.
+func (m *Module) storeLocal(key string, v any) {
+	defer func() {
+		if r := recover(); r != nil {
+			m.log.Info("Could not write local storage", "key", key, "error", fmt.Sprint(r))
+		}
+	}()
+
+	s := js.Global().Get("localStorage")
+	if !s.Truthy() {
+		return
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	s.Call("setItem", key, string(b))
+}
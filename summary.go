@@ -0,0 +1,68 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// summaryPhrases are the phrases of the spoken summary, kept together so
+// they can be translated.
+var summaryPhrases = struct {
+	Degrees      string
+	Calm         string
+	LightWind    string
+	ModerateWind string
+	StrongWind   string
+	RainLikely   string
+}{
+	Degrees:      "degrees",
+	Calm:         "calm",
+	LightWind:    "light wind",
+	ModerateWind: "moderate wind",
+	StrongWind:   "strong wind",
+	RainLikely:   "rain likely today",
+}
+
+// Wind speeds in metres per second at which the wind is described as
+// light, moderate and strong.
+const (
+	lightWind    = 0.5
+	moderateWind = 5.5
+	strongWind   = 10.8
+)
+
+// summary returns a single sentence describing the current weather, for
+// screen readers. Temperatures are formatted with temp, and the wind
+// speed is in the given unit.
+func summary(d data, temp func(float64) string, windUnit string) string {
+	c := d.Current
+	if c.Unix == 0 {
+		return ""
+	}
+
+	var parts []string
+	if desc := c.Weather.Description(); desc != "" {
+		r, n := utf8.DecodeRuneInString(desc)
+		parts = append(parts, string(unicode.ToUpper(r))+desc[n:])
+	}
+	parts = append(parts, temp(c.Main.Temp)+" "+summaryPhrases.Degrees)
+
+	switch speed := convertSpeed(c.Wind.Speed, windUnit, "m/s"); {
+	case speed >= strongWind:
+		parts = append(parts, summaryPhrases.StrongWind)
+	case speed >= moderateWind:
+		parts = append(parts, summaryPhrases.ModerateWind)
+	case speed >= lightWind:
+		parts = append(parts, summaryPhrases.LightWind)
+	default:
+		parts = append(parts, summaryPhrases.Calm)
+	}
+
+	if c.Day.Rain > 0 || c.Day.Pop >= 0.5 {
+		parts = append(parts, summaryPhrases.RainLikely)
+	}
+	return strings.Join(parts, ", ")
+}
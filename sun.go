@@ -0,0 +1,135 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// goldenHourLength is the rough length of the golden hour after sunrise
+// and before sunset.
+const goldenHourLength = time.Hour
+
+// window is a period of time.
+type window struct {
+	Start time.Time
+	End   time.Time
+}
+
+// format formats the window using the given time layout.
+func (w window) format(layout string) string {
+	return w.Start.Format(layout) + "–" + w.End.Format(layout)
+}
+
+// goldenHours returns the golden hour windows following sunrise and
+// preceding sunset.
+func goldenHours(sunrise, sunset time.Time) (morning, evening window) {
+	morning = window{Start: sunrise, End: sunrise.Add(goldenHourLength)}
+	evening = window{Start: sunset.Add(-goldenHourLength), End: sunset}
+	return morning, evening
+}
+
+// goldenHour is the formatted golden hour windows of the day.
+type goldenHour struct {
+	Morning string
+	Evening string
+}
+
+// goldenHour returns the golden hours of the current day in the timezone
+// of the location, or nil when the sun times are unknown.
+func (c current) goldenHour(layout string) *goldenHour {
+	if c.Sys.Sunrise == 0 || c.Sys.Sunset == 0 {
+		return nil
+	}
+
+	loc := c.Location()
+	morning, evening := goldenHours(time.Unix(c.Sys.Sunrise, 0).In(loc), time.Unix(c.Sys.Sunset, 0).In(loc))
+	return &goldenHour{
+		Morning: morning.format(layout),
+		Evening: evening.format(layout),
+	}
+}
+
+// daylightLength is the daylight of the day, recorded to compare the
+// following day against.
+type daylightLength struct {
+	date   string
+	length time.Duration
+
+	yesterday time.Duration
+}
+
+// daylight is the formatted daylight of the day.
+type daylight struct {
+	Length string
+	Change string
+}
+
+// recordDaylight records the daylight of the current day, returning it
+// with the change from yesterday when yesterday was recorded, or nil
+// when the sun times are unknown.
+func (m *Module) recordDaylight(c current) *daylight {
+	if c.Sys.Sunrise == 0 || c.Sys.Sunset == 0 {
+		return nil
+	}
+
+	sunrise := time.Unix(c.Sys.Sunrise, 0).In(c.Location())
+	length := time.Duration(c.Sys.Sunset-c.Sys.Sunrise) * time.Second
+
+	rec := &m.daylight
+	if date := sunrise.Format(time.DateOnly); date != rec.date {
+		rec.yesterday = 0
+		if rec.date == sunrise.AddDate(0, 0, -1).Format(time.DateOnly) {
+			rec.yesterday = rec.length
+		}
+		rec.date = date
+	}
+	rec.length = length
+
+	dl := &daylight{Length: formatDuration(length) + " of daylight"}
+	if rec.yesterday != 0 {
+		dl.Change = formatChange(length - rec.yesterday)
+	}
+	return dl
+}
+
+// formatDuration formats a duration in hours and minutes.
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// formatChange formats a change in daylight in minutes, with its sign.
+func formatChange(d time.Duration) string {
+	mins := int(d.Round(time.Minute).Minutes())
+	if mins >= 0 {
+		return fmt.Sprintf("+%dm", mins)
+	}
+	return fmt.Sprintf("%dm", mins)
+}
+
+// sunCountdown returns the time until the next sunrise or sunset, or an
+// empty string when the sun times are unknown. After sunset, it counts
+// down to the sunrise of the following day in the location.
+func sunCountdown(now time.Time, c current) string {
+	if c.Sys.Sunrise == 0 || c.Sys.Sunset == 0 {
+		return ""
+	}
+
+	sunrise := time.Unix(c.Sys.Sunrise, 0).In(c.Location())
+	sunset := time.Unix(c.Sys.Sunset, 0).In(c.Location())
+	switch {
+	case now.Before(sunrise):
+		return "Sunrise in " + formatDuration(sunrise.Sub(now))
+	case now.Before(sunset):
+		return "Sunset in " + formatDuration(sunset.Sub(now))
+	}
+
+	// The following sunrise is close enough to a day after today's.
+	next := sunrise.AddDate(0, 0, 1)
+	if !now.Before(next) {
+		return ""
+	}
+	return "Sunrise in " + formatDuration(next.Sub(now))
+}
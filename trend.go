@@ -0,0 +1,122 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const historyWindow = 25 * time.Hour
+
+// historyKey is the local storage key of the temperature history.
+const historyKey = "glasslabs.weather.history"
+
+type tempSample struct {
+	At   time.Time `json:"at"`
+	Temp float64   `json:"temp"`
+}
+
+// historyCache is the temperature history kept in local storage, so the
+// trend is known straight after a restart. It is only used for the same
+// place and units it was recorded in.
+type historyCache struct {
+	Place   string       `json:"place"`
+	Units   string       `json:"units"`
+	Samples []tempSample `json:"samples"`
+}
+
+// historyPlace returns the configured place the history is recorded for.
+func historyPlace(c Config) string {
+	switch {
+	case c.Lat != nil && c.Lon != nil:
+		return fmt.Sprintf("%g,%g", *c.Lat, *c.Lon)
+	case c.LocationName != "":
+		return normalizeLocation(c.LocationName)
+	default:
+		return c.LocationID
+	}
+}
+
+// loadHistory returns the temperature history kept in local storage.
+func (m *Module) loadHistory() []tempSample {
+	var hc historyCache
+	if !m.loadLocal(historyKey, &hc) || hc.Place != historyPlace(m.cfg) || hc.Units != m.cfg.tempUnits() {
+		return nil
+	}
+	return hc.Samples
+}
+
+// record stores the current temperature and returns the temperature
+// observed closest to the same time yesterday, if one is known.
+func (m *Module) record(c current) *float64 {
+	now := time.Unix(c.Unix, 0)
+
+	samples := m.history[:0]
+	for _, s := range m.history {
+		if now.Sub(s.At) <= historyWindow {
+			samples = append(samples, s)
+		}
+	}
+	m.history = append(samples, tempSample{At: now, Temp: c.Main.Temp})
+	m.storeLocal(historyKey, historyCache{Place: historyPlace(m.cfg), Units: m.cfg.tempUnits(), Samples: m.history})
+
+	var (
+		prior *float64
+		best  time.Duration
+	)
+	for _, s := range m.history {
+		age := now.Sub(s.At)
+		if age < 23*time.Hour {
+			continue
+		}
+		diff := (age - 24*time.Hour).Abs()
+		if prior == nil || diff < best {
+			temp := s.Temp
+			prior, best = &temp, diff
+		}
+	}
+	return prior
+}
+
+// smoothedTemp is the running average of the current temperature.
+type smoothedTemp struct {
+	at   int64
+	temp float64
+}
+
+// smoothTemp returns the exponential moving average of the current
+// temperature, weighting the previous average by the factor. Repeated
+// observations do not change the average.
+func (m *Module) smoothTemp(c current, factor float64) float64 {
+	s := &m.smoothed
+	switch {
+	case s.at == 0:
+		s.temp = c.Main.Temp
+	case c.Unix != s.at:
+		s.temp = factor*s.temp + (1-factor)*c.Main.Temp
+	}
+	s.at = c.Unix
+	return s.temp
+}
+
+// trend returns the temperature change compared to yesterday, formatted
+// with the temperature formatter.
+func trend(c current, temp func(float64) string) string {
+	if c.Yesterday == nil {
+		return ""
+	}
+
+	delta := c.Main.Temp - *c.Yesterday
+	s := temp(math.Abs(delta))
+	switch {
+	case strings.Trim(s, "+0.") == "":
+		return "Same as yesterday"
+	case delta > 0:
+		return s + "° warmer than yesterday"
+	default:
+		return s + "° colder than yesterday"
+	}
+}
@@ -0,0 +1,157 @@
+//go:build js && wasm
+
+package main
+
+import "fmt"
+
+const kelvinOffset = 273.15
+
+// tempUnits maps temperature units to the units they are displayed in.
+var tempUnits = map[string]string{
+	"C": "metric",
+	"F": "imperial",
+	"K": "standard",
+}
+
+// speedUnits are the available wind speed units, in metres per second.
+var speedUnits = map[string]float64{
+	"m/s":  1,
+	"km/h": 1 / 3.6,
+	"mph":  0.44704,
+	"kn":   1852.0 / 3600,
+}
+
+// pressureUnits are the available pressure units, in hectopascals.
+var pressureUnits = map[string]float64{
+	"hPa":  1,
+	"kPa":  10,
+	"inHg": 33.8638866667,
+	"mmHg": 1.33322387415,
+}
+
+// convertTemp converts a temperature between units.
+func convertTemp(temp float64, from, to string) float64 {
+	if from == to {
+		return temp
+	}
+	return fromKelvin(toKelvin(temp, from), to)
+}
+
+// toKelvin converts a temperature in the given units to Kelvin.
+func toKelvin(temp float64, units string) float64 {
+	switch units {
+	case "imperial":
+		return fahrenheitToKelvin(temp)
+	case "standard":
+		return temp
+	default:
+		return celsiusToKelvin(temp)
+	}
+}
+
+// fromKelvin converts a temperature in Kelvin to the given units.
+func fromKelvin(temp float64, units string) float64 {
+	switch units {
+	case "imperial":
+		return kelvinToFahrenheit(temp)
+	case "standard":
+		return temp
+	default:
+		return kelvinToCelsius(temp)
+	}
+}
+
+// celsiusToFahrenheit converts degrees Celsius to degrees Fahrenheit.
+func celsiusToFahrenheit(c float64) float64 {
+	return c*9/5 + 32
+}
+
+// fahrenheitToCelsius converts degrees Fahrenheit to degrees Celsius.
+func fahrenheitToCelsius(f float64) float64 {
+	return (f - 32) * 5 / 9
+}
+
+// celsiusToKelvin converts degrees Celsius to Kelvin.
+func celsiusToKelvin(c float64) float64 {
+	return c + kelvinOffset
+}
+
+// kelvinToCelsius converts Kelvin to degrees Celsius.
+func kelvinToCelsius(k float64) float64 {
+	return k - kelvinOffset
+}
+
+// fahrenheitToKelvin converts degrees Fahrenheit to Kelvin.
+func fahrenheitToKelvin(f float64) float64 {
+	return celsiusToKelvin(fahrenheitToCelsius(f))
+}
+
+// kelvinToFahrenheit converts Kelvin to degrees Fahrenheit.
+func kelvinToFahrenheit(k float64) float64 {
+	return celsiusToFahrenheit(kelvinToCelsius(k))
+}
+
+// convertSpeed converts a wind speed between speed units.
+func convertSpeed(speed float64, from, to string) float64 {
+	if from == to {
+		return speed
+	}
+	return speed * speedUnits[from] / speedUnits[to]
+}
+
+// convertPressure converts a pressure in hectopascals to the given
+// pressure unit.
+func convertPressure(pres float64, to string) float64 {
+	return pres / pressureUnits[to]
+}
+
+// formatPressure formats a pressure in the given unit, keeping the
+// decimals needed to be meaningful in larger units.
+func formatPressure(pres float64, unit string) string {
+	switch unit {
+	case "inHg":
+		return fmt.Sprintf("%.2f", pres)
+	case "kPa":
+		return fmt.Sprintf("%.1f", pres)
+	default:
+		return fmt.Sprintf("%.f", pres)
+	}
+}
+
+// convert converts the temperatures in the data between units.
+func (d *data) convert(from, to string) {
+	if from == to {
+		return
+	}
+
+	d.Current.Main.Temp = convertTemp(d.Current.Main.Temp, from, to)
+	if fl := d.Current.Main.FeelsLike; fl != nil {
+		// The value may be shared with the cached response.
+		temp := convertTemp(*fl, from, to)
+		d.Current.Main.FeelsLike = &temp
+	}
+	for i := range d.Forecast.List {
+		d.Forecast.List[i].convert(from, to)
+	}
+	for i := range d.Hourly.List {
+		d.Hourly.List[i].Main.Temp = convertTemp(d.Hourly.List[i].Main.Temp, from, to)
+	}
+}
+
+// convert converts the temperatures of the day between units.
+func (d *day) convert(from, to string) {
+	d.Temp.Min = convertTemp(d.Temp.Min, from, to)
+	d.Temp.Max = convertTemp(d.Temp.Max, from, to)
+	d.Temp.Morn = convertTemp(d.Temp.Morn, from, to)
+	d.Temp.Day = convertTemp(d.Temp.Day, from, to)
+	d.Temp.Eve = convertTemp(d.Temp.Eve, from, to)
+	d.Temp.Night = convertTemp(d.Temp.Night, from, to)
+	if d.FeelsLike != nil {
+		fl := *d.FeelsLike
+		fl.Morn = convertTemp(fl.Morn, from, to)
+		fl.Day = convertTemp(fl.Day, from, to)
+		fl.Eve = convertTemp(fl.Eve, from, to)
+		fl.Night = convertTemp(fl.Night, from, to)
+		d.FeelsLike = &fl
+	}
+}
@@ -0,0 +1,228 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"errors"
+	"math"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const weatherAPIURL = "https://api.weatherapi.com/v1/"
+
+// weatherAPI provides weather data from WeatherAPI.com.
+type weatherAPI struct {
+	m *Module
+}
+
+func (p weatherAPI) Name() string {
+	return "WeatherAPI.com"
+}
+
+// weatherAPIMaxDays is the maximum number of forecast days.
+const weatherAPIMaxDays = 14
+
+func (p weatherAPI) maxDays() int {
+	return weatherAPIMaxDays
+}
+
+func (p weatherAPI) jobs(d *data) ([]job, error) {
+	m := p.m
+
+	q := url.Values{}
+	switch c := m.location(); {
+	case c != nil:
+		q.Set("q", strconv.FormatFloat(c.Lat, 'f', -1, 64)+","+strconv.FormatFloat(c.Lon, 'f', -1, 64))
+	case m.cfg.LocationName != "":
+		q.Set("q", m.cfg.LocationName)
+	default:
+		return nil, errors.New("a location name or coordinates are required")
+	}
+	q.Set("key", m.appID())
+	q.Set("days", strconv.Itoa(forecastCount(p, m.cfg.ForecastDays)))
+	q.Set("aqi", "no")
+	q.Set("alerts", "no")
+	addParams(q, m.cfg.ExtraParams)
+
+	return []job{
+		{name: "forecast", fn: func() error {
+			var resp weatherAPIResponse
+			err := m.get(weatherAPIURL+"forecast.json", q, &resp)
+			err = cached(err, &resp, &m.cached.WeatherAPI)
+			resp.fill(d, m.cfg.fetchUnits())
+			return err
+		}},
+	}, nil
+}
+
+type weatherAPICondition struct {
+	Text string `json:"text"`
+	Code int    `json:"code"`
+}
+
+type weatherAPIResponse struct {
+	Location struct {
+		Localtime      string `json:"localtime"`
+		LocaltimeEpoch int64  `json:"localtime_epoch"`
+	} `json:"location"`
+	Current struct {
+		LastUpdatedEpoch int64               `json:"last_updated_epoch"`
+		TempC            float64             `json:"temp_c"`
+		TempF            float64             `json:"temp_f"`
+		FeelsLikeC       float64             `json:"feelslike_c"`
+		FeelsLikeF       float64             `json:"feelslike_f"`
+		IsDay            int                 `json:"is_day"`
+		Condition        weatherAPICondition `json:"condition"`
+		WindKPH          float64             `json:"wind_kph"`
+		WindMPH          float64             `json:"wind_mph"`
+		WindDegree       float64             `json:"wind_degree"`
+		PressureMB       float64             `json:"pressure_mb"`
+		Humidity         float64             `json:"humidity"`
+		Cloud            float64             `json:"cloud"`
+	} `json:"current"`
+	Forecast struct {
+		ForecastDay []struct {
+			DateEpoch int64 `json:"date_epoch"`
+			Day       struct {
+				MaxTempC          float64             `json:"maxtemp_c"`
+				MaxTempF          float64             `json:"maxtemp_f"`
+				MinTempC          float64             `json:"mintemp_c"`
+				MinTempF          float64             `json:"mintemp_f"`
+				AvgTempC          float64             `json:"avgtemp_c"`
+				AvgTempF          float64             `json:"avgtemp_f"`
+				TotalPrecipMM     float64             `json:"totalprecip_mm"`
+				DailyChanceOfRain float64             `json:"daily_chance_of_rain"`
+				Condition         weatherAPICondition `json:"condition"`
+			} `json:"day"`
+		} `json:"forecastday"`
+	} `json:"forecast"`
+}
+
+// fill maps the response into the data, converting to the given units.
+func (r weatherAPIResponse) fill(d *data, units string) {
+	temp := func(c, f float64) float64 {
+		switch units {
+		case "imperial":
+			return f
+		case "standard":
+			return celsiusToKelvin(c)
+		default:
+			return c
+		}
+	}
+
+	cur := r.Current
+	d.Current.Unix = cur.LastUpdatedEpoch
+	d.Current.Timezone = r.offset()
+	d.Current.Main.Temp = temp(cur.TempC, cur.TempF)
+	feelsLike := temp(cur.FeelsLikeC, cur.FeelsLikeF)
+	d.Current.Main.FeelsLike = &feelsLike
+	d.Current.Main.Humidity = cur.Humidity
+	d.Current.Main.Pressure = cur.PressureMB
+	d.Current.Wind.Speed = cur.WindKPH / 3.6
+	if units == "imperial" {
+		d.Current.Wind.Speed = cur.WindMPH
+	}
+	d.Current.Wind.Deg = cur.WindDegree
+	d.Current.Clouds.All = cur.Cloud
+	d.Current.Weather = cur.Condition.weather(cur.IsDay == 1)
+
+	d.Forecast.List = make([]day, 0, len(r.Forecast.ForecastDay))
+	for _, fd := range r.Forecast.ForecastDay {
+		dy := day{
+			// The date is midnight UTC, so use noon on the date in the location.
+			Unix:    fd.DateEpoch + 12*60*60 - int64(d.Current.Timezone),
+			Weather: fd.Day.Condition.weather(true),
+			Rain:    number(fd.Day.TotalPrecipMM),
+			Pop:     number(fd.Day.DailyChanceOfRain / 100),
+		}
+		dy.Temp.Min = temp(fd.Day.MinTempC, fd.Day.MinTempF)
+		dy.Temp.Max = temp(fd.Day.MaxTempC, fd.Day.MaxTempF)
+		dy.Temp.Day = temp(fd.Day.AvgTempC, fd.Day.AvgTempF)
+		d.Forecast.List = append(d.Forecast.List, dy)
+	}
+}
+
+// offset returns the time zone offset of the location in seconds, which
+// is derived from the local time as the zone name cannot be loaded.
+func (r weatherAPIResponse) offset() int {
+	local, err := time.Parse("2006-1-2 15:04", r.Location.Localtime)
+	if err != nil {
+		return 0
+	}
+	diff := local.Sub(time.Unix(r.Location.LocaltimeEpoch, 0)).Seconds()
+	// Offsets are multiples of 15 minutes.
+	return int(math.Round(diff/900) * 900)
+}
+
+// weather returns the condition as OpenWeatherMap weather, allowing the
+// existing icon tables to be used.
+func (c weatherAPICondition) weather(isDay bool) weather {
+	code := unknownWeatherAPICode
+	if owm, ok := weatherAPICodes[c.Code]; ok {
+		code = owm
+	}
+	if isDay {
+		code += "d"
+	} else {
+		code += "n"
+	}
+	return weather{{IconCode: code, Description: c.Text}}
+}
+
+const unknownWeatherAPICode = "00"
+
+// weatherAPICodes maps WeatherAPI.com condition codes to OpenWeatherMap
+// icon codes, without the time of day.
+var weatherAPICodes = map[int]string{
+	1000: "01", // Sunny / Clear.
+	1003: "02", // Partly cloudy.
+	1006: "03", // Cloudy.
+	1009: "04", // Overcast.
+	1030: "50", // Mist.
+	1063: "10", // Patchy rain possible.
+	1066: "13", // Patchy snow possible.
+	1069: "13", // Patchy sleet possible.
+	1072: "09", // Patchy freezing drizzle possible.
+	1087: "11", // Thundery outbreaks possible.
+	1114: "13", // Blowing snow.
+	1117: "13", // Blizzard.
+	1135: "50", // Fog.
+	1147: "50", // Freezing fog.
+	1150: "09", // Patchy light drizzle.
+	1153: "09", // Light drizzle.
+	1168: "09", // Freezing drizzle.
+	1171: "09", // Heavy freezing drizzle.
+	1180: "10", // Patchy light rain.
+	1183: "10", // Light rain.
+	1186: "10", // Moderate rain at times.
+	1189: "10", // Moderate rain.
+	1192: "10", // Heavy rain at times.
+	1195: "10", // Heavy rain.
+	1198: "09", // Light freezing rain.
+	1201: "09", // Moderate or heavy freezing rain.
+	1204: "13", // Light sleet.
+	1207: "13", // Moderate or heavy sleet.
+	1210: "13", // Patchy light snow.
+	1213: "13", // Light snow.
+	1216: "13", // Patchy moderate snow.
+	1219: "13", // Moderate snow.
+	1222: "13", // Patchy heavy snow.
+	1225: "13", // Heavy snow.
+	1237: "13", // Ice pellets.
+	1240: "10", // Light rain shower.
+	1243: "10", // Moderate or heavy rain shower.
+	1246: "10", // Torrential rain shower.
+	1249: "13", // Light sleet showers.
+	1252: "13", // Moderate or heavy sleet showers.
+	1255: "13", // Light snow showers.
+	1258: "13", // Moderate or heavy snow showers.
+	1261: "13", // Light showers of ice pellets.
+	1264: "13", // Moderate or heavy showers of ice pellets.
+	1273: "11", // Patchy light rain with thunder.
+	1276: "11", // Moderate or heavy rain with thunder.
+	1279: "11", // Patchy light snow with thunder.
+	1282: "11", // Moderate or heavy snow with thunder.
+}
@@ -0,0 +1,57 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"math"
+)
+
+var compassPoints = []string{
+	"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE",
+	"S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW",
+}
+
+// cardinal returns the compass label for the given degrees. Points
+// must be either 8 or 16, defaulting to 8 for any other value.
+func cardinal(deg float64, points int) string {
+	if points != 16 {
+		points = 8
+	}
+
+	deg = math.Mod(deg, 360)
+	if deg < 0 {
+		deg += 360
+	}
+	sector := 360 / float64(points)
+	i := int(math.Floor(deg/sector+0.5)) % points
+
+	return compassPoints[i*len(compassPoints)/points]
+}
+
+// windUnit returns the wind speed unit for the given units.
+func windUnit(units string) string {
+	if units == "imperial" {
+		return "mph"
+	}
+	return "m/s"
+}
+
+// Cell returns the wind as an arrow pointing the way the wind blows,
+// followed by the speed with the given number of decimals and the unit,
+// or nothing when there is no wind data.
+func (w wind) Cell(decimals int) template.HTML {
+	if w.Speed == 0 && w.Deg == 0 {
+		return ""
+	}
+
+	// The direction is where the wind comes from, so the arrow points
+	// the opposite way.
+	rot := math.Mod(w.Deg+180, 360)
+	return template.HTML(fmt.Sprintf(
+		`<span class="wind-arrow" style="transform: rotate(%.fdeg)">&uarr;</span> %s <span class="unit">%s</span>`,
+		rot, formatNumber(w.Speed, decimals), html.EscapeString(w.Unit),
+	))
+}